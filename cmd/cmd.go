@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	commandMigrate   = "migrate"
+	commandRecluster = "recluster"
+	commandImport    = "import"
+	commandExport    = "export"
+)
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Execute parses flags and runs the command given by the first argument. Without arguments it runs the server.
+func Execute() error {
+	config := &Config{}
+	config.InitFlags()
+
+	pflag.Parse()
+
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	switch command := pflag.Arg(0); command {
+	case "":
+		return ExecuteServer(config)
+	case commandMigrate:
+		return ExecuteMigrate(config)
+	case commandRecluster:
+		return ExecuteRecluster(config)
+	case commandImport:
+		return ExecuteImport(config, pflag.Arg(1))
+	case commandExport:
+		return ExecuteExport(config, pflag.Arg(1))
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, command)
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/devchallenge/article-similarity/internal/archive"
+	"github.com/devchallenge/article-similarity/internal/article"
+)
+
+// ExecuteExport writes all stored articles to the standard output like GET /export. The format is ndjson
+// when it is empty.
+func ExecuteExport(config *Config, format string) error {
+	if format == "" {
+		format = archive.FormatNDJSON
+	}
+
+	st, closeStorage, err := openStorage(config)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+
+	defer closeStorage()
+
+	sim, err := newSimilarity(config)
+	if err != nil {
+		return err
+	}
+
+	art := article.New(sim, newIndex(config, sim), st)
+
+	exported, err := archive.Export(context.Background(), art, format, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to export after %d exported articles: %w", exported, err)
+	}
+
+	log.Printf("exported %d articles", exported)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/devchallenge/article-similarity/internal/archive"
+	"github.com/devchallenge/article-similarity/internal/article"
+)
+
+var ErrNoImportFile = errors.New("no file to import")
+
+// ExecuteImport stores articles of the newline-delimited JSON file like POST /articles:batch and writes results
+// of its lines to the standard output.
+func ExecuteImport(config *Config, path string) error {
+	if path == "" {
+		return ErrNoImportFile
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file=%s: %w", path, err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("failed to close file=%s: %v", path, err)
+		}
+	}()
+
+	st, closeStorage, err := openStorage(config)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+
+	defer closeStorage()
+
+	sim, err := newSimilarity(config)
+	if err != nil {
+		return err
+	}
+
+	art := article.New(sim, newIndex(config, sim), st)
+	if err := art.EnsureIndex(context.Background()); err != nil {
+		return fmt.Errorf("failed to ensure index: %w", err)
+	}
+
+	stored, err := archive.Import(context.Background(), art, f, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to import after %d stored articles: %w", stored, err)
+	}
+
+	log.Printf("imported %d articles", stored)
+
+	return nil
+}
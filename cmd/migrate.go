@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/devchallenge/article-similarity/internal/article"
+)
+
+// ExecuteMigrate tokenizes stored articles with the configured normalization and reindexes them. It backfills
+// tokens of articles stored by previous versions and applies changed normalization flags to stored articles.
+func ExecuteMigrate(config *Config) error {
+	st, closeStorage, err := openStorage(config)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+
+	defer closeStorage()
+
+	sim, err := newSimilarity(config)
+	if err != nil {
+		return err
+	}
+
+	art := article.New(sim, newIndex(config, sim), st)
+
+	migrated, err := art.MigrateTokens(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to migrate tokens: %w", err)
+	}
+
+	log.Printf("migrated tokens of %d articles", migrated)
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/devchallenge/article-similarity/internal/article"
+)
+
+// ExecuteRecluster recomputes duplicates, unique articles and duplicate groups of stored articles with
+// the configured similarity. It must be run after the similarity algorithm or thresholds are changed, otherwise
+// stored duplicates keep the configuration active when articles were added. Interrupted reclustering is resumed
+// by running the command again.
+func ExecuteRecluster(config *Config) error {
+	st, closeStorage, err := openStorage(config)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+
+	defer closeStorage()
+
+	sim, err := newSimilarity(config)
+	if err != nil {
+		return err
+	}
+
+	art := article.New(sim, newIndex(config, sim), st)
+
+	status, err := article.NewReclusterer(art, st).Run(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to recluster: %w", err)
+	}
+
+	log.Printf("reclustered %d of %d articles, deleted %d", status.Reclustered, status.Processed, status.Deleted)
+
+	return nil
+}
@@ -2,52 +2,118 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/go-openapi/loads"
 	"github.com/spf13/pflag"
-	mg "go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/devchallenge/article-similarity/internal/article"
 	"github.com/devchallenge/article-similarity/internal/http"
 	"github.com/devchallenge/article-similarity/internal/http/restapi"
 	"github.com/devchallenge/article-similarity/internal/http/restapi/operations"
-	"github.com/devchallenge/article-similarity/internal/mongo"
+	"github.com/devchallenge/article-similarity/internal/lsh"
 	"github.com/devchallenge/article-similarity/internal/similarity"
 )
 
 const (
-	defaultSimilarityThreshold = 0.95
+	defaultSimilarityThreshold    = 0.95
+	defaultCrossLanguageThreshold = 1.0
 
-	defaultStorageConnectTimeout = 10 * time.Second
+	defaultLSHBands = 20
+	defaultLSHRows  = 5
 
-	irregularVerbFilePath = "assets/irregular_verbs.csv"
+	defaultStorageConnectTimeout = 10 * time.Second
 )
 
+var ErrInvalidConfig = errors.New("invalid config")
+
 type Config struct {
-	SimilarityThreshold float64
-	MongoHost           string
-	MongoPort           int
-	MongoDatabase       string
+	SimilarityAlgorithm      string
+	SimilarityThreshold      float64
+	CrossLanguageThreshold   float64
+	Storage                  string
+	DataDir                  string
+	MongoHost                string
+	MongoPort                int
+	MongoDatabase            string
+	LSHBands                 int
+	LSHRows                  int
+	Normalization            []string
+	Stemming                 bool
+	IrregularVerbs           string
+	LevenshteinInsertCost    int
+	LevenshteinDeleteCost    int
+	LevenshteinReplaceCost   int
+	LevenshteinTransposeCost int
+	LevenshteinWeights       string
+
+	// weights are the word weights loaded from the LevenshteinWeights file by Validate.
+	weights similarity.Weights
 }
 
 func (c *Config) InitFlags() {
+	pflag.StringVar(&c.SimilarityAlgorithm, "similarity_algorithm", string(similarity.AlgorithmLevenshtein),
+		"article similarity algorithm: levenshtein, damerau_levenshtein, jaccard, cosine, simhash or jaro_winkler")
 	pflag.Float64Var(&c.SimilarityThreshold, "similarity_threshold", defaultSimilarityThreshold,
-		"article similarity threshold in percents")
+		"article similarity threshold in percents, default depends on similarity algorithm")
+	pflag.Float64Var(&c.CrossLanguageThreshold, "cross_language_threshold", defaultCrossLanguageThreshold,
+		"similarity threshold of articles in different languages")
+	pflag.StringVar(&c.Storage, "storage", storageMongo, "storage backend: mongo, file or memory")
+	pflag.StringVar(&c.DataDir, "data_dir", "data", "data directory of file storage")
 	pflag.StringVar(&c.MongoHost, "mongo_host", "localhost", "mongodb host")
 	pflag.IntVar(&c.MongoPort, "mongo_port", 27017, "mongodb port")
 	pflag.StringVar(&c.MongoDatabase, "mongo_database", "dev", "mongodb database name")
+	pflag.IntVar(&c.LSHBands, "lsh_bands", defaultLSHBands, "number of LSH bands used to find candidate duplicates")
+	pflag.IntVar(&c.LSHRows, "lsh_rows", defaultLSHRows, "number of MinHash rows in LSH band")
+	pflag.StringSliceVar(&c.Normalization, "normalization", []string{
+		string(similarity.StageNFKC), string(similarity.StageLetters),
+		string(similarity.StageDiacritics), string(similarity.StageCase),
+	}, "text normalization stages: nfkc, letters, diacritics and case")
+	pflag.BoolVar(&c.Stemming, "stemming", false,
+		"stem words of English articles with Porter stemmer, words of other languages are stemmed anyway")
+	pflag.StringVar(&c.IrregularVerbs, "irregular_verbs", "",
+		"CSV file of irregular verbs with infinitive, simple past and past participle, built-in list if empty")
+	pflag.IntVar(&c.LevenshteinInsertCost, "levenshtein_insert_cost", 1, "Levenshtein cost of a word insertion")
+	pflag.IntVar(&c.LevenshteinDeleteCost, "levenshtein_delete_cost", 1, "Levenshtein cost of a word deletion")
+	pflag.IntVar(&c.LevenshteinReplaceCost, "levenshtein_replace_cost", 1, "Levenshtein cost of a word substitution")
+	pflag.IntVar(&c.LevenshteinTransposeCost, "levenshtein_transpose_cost", 1,
+		"cost of a transposition of adjacent words for damerau_levenshtein algorithm")
+	pflag.StringVar(&c.LevenshteinWeights, "levenshtein_weights", "",
+		"CSV file of normalized word weights for Levenshtein algorithm, e.g. IDF, all words weigh 1 if empty")
 }
 
-func ExecuteServer() error {
-	config := &Config{}
-	config.InitFlags()
+// Validate checks the flags before the storage is opened. The word weights are loaded here, so an invalid weights
+// file fails the command at once.
+func (c *Config) Validate() error {
+	if c.LSHBands < 1 || c.LSHRows < 1 {
+		return fmt.Errorf("%w: lsh_bands=%d and lsh_rows=%d must be at least 1", ErrInvalidConfig, c.LSHBands,
+			c.LSHRows)
+	}
 
-	pflag.Parse()
+	lev := similarity.Levenshtein{
+		InsertCost:    c.LevenshteinInsertCost,
+		DeleteCost:    c.LevenshteinDeleteCost,
+		ReplaceCost:   c.LevenshteinReplaceCost,
+		TransposeCost: c.LevenshteinTransposeCost,
+	}
+	if err := lev.Validate(); err != nil {
+		return fmt.Errorf("invalid levenshtein cost flags: %w", err)
+	}
 
+	if c.LevenshteinWeights != "" {
+		if err := c.weights.Load(c.LevenshteinWeights); err != nil {
+			return fmt.Errorf("failed to load word weights: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ExecuteServer serves the API.
+func ExecuteServer(config *Config) error {
 	swaggerSpec, err := loads.Embedded(restapi.SwaggerJSON, restapi.FlatSwaggerJSON)
 	if err != nil {
 		return fmt.Errorf("failed to embedded spec: %w", err)
@@ -63,39 +129,112 @@ func ExecuteServer() error {
 		}
 	}()
 
-	mongoURI := fmt.Sprintf("mongodb://%s:%d", config.MongoHost, config.MongoPort)
-	log.Printf("mongoURI: %s", mongoURI)
-
-	mc, err := mg.NewClient(options.Client().ApplyURI(mongoURI))
+	st, closeStorage, err := openStorage(config)
 	if err != nil {
-		return fmt.Errorf("failed to create mongo: %w", err)
+		return fmt.Errorf("failed to open storage: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultStorageConnectTimeout)
-	defer cancel()
+	defer closeStorage()
 
-	if err := mc.Connect(ctx); err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
+	sim, err := newSimilarity(config)
+	if err != nil {
+		return err
 	}
 
-	defer func() {
-		if err := mc.Disconnect(context.Background()); err != nil {
-			log.Printf("failed to disconnect mongo: %v", err)
-		}
-	}()
+	art := article.New(sim, newIndex(config, sim), st)
+	if err := art.EnsureIndex(context.Background()); err != nil {
+		return fmt.Errorf("failed to ensure index: %w", err)
+	}
+
+	h := http.New(art, article.NewReclusterer(art, st))
+	h.ConfigureHandlers(api)
+	rest.ConfigureAPI()
 
-	st := mongo.New(mc, config.MongoDatabase)
+	return rest.Serve()
+}
 
+// newSimilarity creates the similarity with the normalizer and the metric selected by the config.
+func newSimilarity(config *Config) (*similarity.Similarity, error) {
 	irregularVerb := similarity.IrregularVerb{}
-	if err := irregularVerb.Load(irregularVerbFilePath); err != nil {
-		log.Printf("failed to load irregular verbs from=%s: %v", irregularVerbFilePath, err)
+	if config.IrregularVerbs == "" {
+		if err := irregularVerb.LoadDefault(); err != nil {
+			return nil, fmt.Errorf("failed to load default irregular verbs: %w", err)
+		}
+	} else if err := irregularVerb.Load(config.IrregularVerbs); err != nil {
+		return nil, fmt.Errorf("failed to load irregular verbs: %w", err)
 	}
 
-	art := article.New(similarity.NewSimilarity(config.SimilarityThreshold, irregularVerb), st)
+	stages := make([]similarity.Stage, 0, len(config.Normalization))
+	for _, stage := range config.Normalization {
+		stages = append(stages, similarity.Stage(stage))
+	}
 
-	h := http.New(art)
-	h.ConfigureHandlers(api)
-	rest.ConfigureAPI()
+	normalizer, err := similarity.DefaultNormalizer(irregularVerb).WithStages(stages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create normalizer: %w", err)
+	}
 
-	return rest.Serve()
+	normalizer.StemEnglish = config.Stemming
+
+	metric, err := newMetric(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create similarity metric: %w", err)
+	}
+
+	threshold := config.SimilarityThreshold
+	if !pflag.CommandLine.Changed("similarity_threshold") {
+		threshold = metric.DefaultThreshold()
+	}
+
+	log.Printf("similarity algorithm: %s, threshold: %f, cross-language threshold: %f, normalization: %v, "+
+		"stemming: %t", config.SimilarityAlgorithm, threshold, config.CrossLanguageThreshold, config.Normalization,
+		config.Stemming)
+
+	return similarity.NewSimilarity(threshold, config.CrossLanguageThreshold, normalizer, metric), nil
+}
+
+// newIndex creates the LSH index of the config. Candidates are found by the Jaccard similarity of word sets, so the
+// warning is logged when the threshold is below the crossover of the LSH probability curve, as less similar
+// duplicates are likely never compared.
+func newIndex(config *Config, sim *similarity.Similarity) *lsh.LSH {
+	index := lsh.New(config.LSHBands, config.LSHRows)
+	threshold := sim.MinThreshold()
+
+	log.Printf("lsh bands: %d, rows: %d, candidates of similarity %f are found with probability %f",
+		config.LSHBands, config.LSHRows, threshold, index.Probability(threshold))
+
+	if crossover := index.Crossover(); threshold < crossover {
+		log.Printf("WARNING: similarity threshold %f is below the LSH crossover %f, duplicates with word set "+
+			"Jaccard similarity below the crossover are likely missed, raise --lsh_bands or lower --lsh_rows",
+			threshold, crossover)
+	}
+
+	return index
+}
+
+// newMetric creates the metric selected by the config. Levenshtein metrics get the costs and the word weights,
+// transpositions are edits of Damerau-Levenshtein metric only.
+func newMetric(config *Config) (similarity.Metric, error) {
+	algorithm := similarity.Algorithm(config.SimilarityAlgorithm)
+
+	transposeCost := 0
+
+	switch algorithm {
+	case similarity.AlgorithmLevenshtein:
+	case similarity.AlgorithmDamerauLevenshtein:
+		transposeCost = config.LevenshteinTransposeCost
+	default:
+		return similarity.NewMetric(algorithm)
+	}
+
+	log.Printf("levenshtein costs: insert %d, delete %d, replace %d, transpose %d, word weights: %s",
+		config.LevenshteinInsertCost, config.LevenshteinDeleteCost, config.LevenshteinReplaceCost, transposeCost,
+		config.LevenshteinWeights)
+
+	return similarity.NewWeightedWordLevenshtein(&similarity.Levenshtein{
+		InsertCost:    config.LevenshteinInsertCost,
+		DeleteCost:    config.LevenshteinDeleteCost,
+		ReplaceCost:   config.LevenshteinReplaceCost,
+		TransposeCost: transposeCost,
+	}, config.weights)
 }
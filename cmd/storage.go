@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	mg "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/devchallenge/article-similarity/internal/article"
+	"github.com/devchallenge/article-similarity/internal/file"
+	"github.com/devchallenge/article-similarity/internal/memory"
+	"github.com/devchallenge/article-similarity/internal/mongo"
+)
+
+const (
+	storageMongo  = "mongo"
+	storageFile   = "file"
+	storageMemory = "memory"
+)
+
+var ErrUnknownStorage = errors.New("unknown storage")
+
+// storage is the opened storage with its staging where articles are reclustered.
+type storage struct {
+	article.Storage
+
+	staging func(ctx context.Context) (article.Storage, error)
+	swap    func(ctx context.Context, catchUp func(ctx context.Context) error) error
+}
+
+func (s *storage) Staging(ctx context.Context) (article.Storage, error) {
+	return s.staging(ctx)
+}
+
+func (s *storage) SwapStaging(ctx context.Context, catchUp func(ctx context.Context) error) error {
+	return s.swap(ctx, catchUp)
+}
+
+// openStorage opens the storage selected by the config. The returned function releases the storage.
+func openStorage(config *Config) (*storage, func(), error) {
+	switch config.Storage {
+	case storageMongo:
+		return openMongo(config)
+	case storageFile:
+		log.Printf("data dir: %s", config.DataDir)
+
+		st, err := file.Open(config.DataDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open file storage: %w", err)
+		}
+
+		closeStorage := func() {
+			if err := st.Close(); err != nil {
+				log.Printf("failed to close file storage: %v", err)
+			}
+		}
+
+		return &storage{
+			Storage: st,
+			staging: func(ctx context.Context) (article.Storage, error) {
+				return st.Staging()
+			},
+			swap: st.SwapStaging,
+		}, closeStorage, nil
+	case storageMemory:
+		st := memory.New()
+
+		return &storage{
+			Storage: st,
+			staging: func(ctx context.Context) (article.Storage, error) {
+				return st.Staging(), nil
+			},
+			swap: st.SwapStaging,
+		}, func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownStorage, config.Storage)
+	}
+}
+
+func openMongo(config *Config) (*storage, func(), error) {
+	mongoURI := fmt.Sprintf("mongodb://%s:%d", config.MongoHost, config.MongoPort)
+	log.Printf("mongoURI: %s", mongoURI)
+
+	mc, err := mg.NewClient(options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create mongo: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultStorageConnectTimeout)
+	defer cancel()
+
+	if err := mc.Connect(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	disconnect := func() {
+		if err := mc.Disconnect(context.Background()); err != nil {
+			log.Printf("failed to disconnect mongo: %v", err)
+		}
+	}
+
+	st := mongo.New(mc, config.MongoDatabase)
+	if err := st.EnsureTransactions(ctx); err != nil {
+		disconnect()
+
+		return nil, nil, fmt.Errorf("failed to check transactions: %w", err)
+	}
+
+	if err := st.EnsureIndexes(ctx); err != nil {
+		disconnect()
+
+		return nil, nil, fmt.Errorf("failed to ensure indexes: %w", err)
+	}
+
+	if err := st.ResumeSwap(ctx); err != nil {
+		disconnect()
+
+		return nil, nil, fmt.Errorf("failed to resume staging swap: %w", err)
+	}
+
+	return &storage{
+		Storage: st,
+		staging: func(ctx context.Context) (article.Storage, error) {
+			return st.Staging(ctx)
+		},
+		swap: st.SwapStaging,
+	}, disconnect, nil
+}
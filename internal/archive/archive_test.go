@@ -0,0 +1,196 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+)
+
+// sliceImporter stores imported articles with consecutive ids and keeps them for the export.
+type sliceImporter struct {
+	articles []articlesim.Article
+	err      error
+}
+
+func (s *sliceImporter) ImportArticles(ctx context.Context,
+	inputs []articlesim.ArticleInput) ([]articlesim.Article, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	imported := make([]articlesim.Article, 0, len(inputs))
+
+	for _, input := range inputs {
+		art := articlesim.Article{
+			ID:       articlesim.ArticleID(len(s.articles) + 1),
+			Content:  input.Content,
+			Metadata: input.Metadata,
+			IsUnique: true,
+		}
+		s.articles = append(s.articles, art)
+		imported = append(imported, art)
+	}
+
+	return imported, nil
+}
+
+func (s *sliceImporter) ExportArticles(ctx context.Context, fn func(art articlesim.Article) error) error {
+	for _, art := range s.articles {
+		if err := fn(art); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestImport(t *testing.T) {
+	errStore := errors.New("store failed")
+
+	for name, tc := range map[string]struct {
+		body     string
+		storeErr error
+		stored   int
+		results  string
+		err      error
+	}{
+		"when valid and invalid lines": {
+			body:     "{\"content\":\"a\"}\n\n{\"title\":\"b\"}\n{\"content\":\"\"}\n{\"content\":\"c\",\"tags\":[\"t\"]}",
+			storeErr: nil,
+			stored:   2,
+			results: `{"id":1,"line":1}
+{"error":"invalid article: validation failure list:\nbody.content in body is required","line":3}
+{"error":"empty content","line":4}
+{"id":2,"line":5}
+`,
+			err: nil,
+		},
+		"when batch fails": {
+			body:     "{\"content\":\"a\"}\nnot json\n",
+			storeErr: errStore,
+			stored:   0,
+			results: `{"error":"failed to store article","line":1}
+{"error":"invalid article: invalid character 'o' in literal null (expecting 'u')","line":2}
+`,
+			err: errStore,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var results bytes.Buffer
+
+			stored, err := Import(context.Background(), &sliceImporter{articles: nil, err: tc.storeErr},
+				strings.NewReader(tc.body), &results)
+
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err))
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.stored, stored)
+			assert.Equal(t, tc.results, results.String())
+		})
+	}
+}
+
+// failingReader returns the error once the body is read.
+type failingReader struct {
+	body io.Reader
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, r.err
+	}
+
+	return n, err
+}
+
+func TestImport_ReadFails(t *testing.T) {
+	errRead := errors.New("read failed")
+	body := strings.Repeat("{\"content\":\"a\"}\n", importBatchSize+1) + "{\"content\":"
+
+	var results bytes.Buffer
+
+	stored, err := Import(context.Background(), &sliceImporter{articles: nil, err: nil},
+		&failingReader{body: strings.NewReader(body), err: errRead}, &results)
+
+	assert.True(t, errors.Is(err, errRead))
+	assert.Equal(t, importBatchSize, stored)
+
+	lines := strings.Split(strings.TrimSpace(results.String()), "\n")
+	require.Len(t, lines, importBatchSize+1)
+	// the unfinished batch is not imported from its first line
+	assert.Equal(t, `{"error":"lines from this one are not imported: failed to read line=102: read failed","line":101}`,
+		lines[importBatchSize])
+}
+
+func TestExport(t *testing.T) {
+	exporter := &sliceImporter{articles: []articlesim.Article{
+		{
+			ID:       1,
+			Content:  "a, b",
+			IsUnique: true,
+			Duplicates: []articlesim.Duplicate{
+				{ID: 2, Score: 0.5, Algorithm: "jaccard", Threshold: 0.4},
+			},
+			DuplicateIDs:     []articlesim.ArticleID{2},
+			DuplicateGroupID: 1,
+			Metadata:         articlesim.Metadata{Tags: []string{"x", "y"}},
+		},
+	}, err: nil}
+
+	for name, tc := range map[string]struct {
+		format string
+		output string
+		err    error
+	}{
+		"when ndjson": {
+			format: FormatNDJSON,
+			output: `{"content":"a, b","duplicate_article_ids":[2],"duplicate_group_id":1,` +
+				`"duplicates":[{"algorithm":"jaccard","id":2,"score":0.5,"threshold":0.4}],"id":1,"is_unique":true,` +
+				`"tags":["x","y"]}` + "\n",
+			err: nil,
+		},
+		"when csv": {
+			format: FormatCSV,
+			output: "id,content,is_unique,duplicate_group_id,duplicate_ids,scores,algorithms,thresholds,title," +
+				"source_url,author,published_at,tags\n" +
+				"1,\"a, b\",true,1,2,0.5,jaccard,0.4,,,,,x;y\n",
+			err: nil,
+		},
+		"when unknown format": {
+			format: "xml",
+			output: "",
+			err:    ErrUnknownFormat,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			exported, err := Export(context.Background(), exporter, tc.format, &output)
+
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err))
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, exported)
+			assert.Equal(t, tc.output, output.String())
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/http/models"
+	"github.com/devchallenge/article-similarity/internal/http/restapi/operations"
+)
+
+var (
+	ErrEmptyContent     = errors.New("empty content")
+	ErrInvalidSourceURL = errors.New("invalid source url")
+)
+
+// ParseArticle returns the content with the metadata of the article body. The content must not be empty and
+// the source URL must have the host of the outlet.
+func ParseArticle(body operations.PostArticlesBody) (articlesim.ArticleInput, error) {
+	if *body.Content == "" {
+		return articlesim.ArticleInput{}, ErrEmptyContent
+	}
+
+	sourceURL := body.SourceURL.String()
+	if sourceURL != "" && articlesim.SourceOf(sourceURL) == "" {
+		return articlesim.ArticleInput{}, fmt.Errorf("%w: %s", ErrInvalidSourceURL, sourceURL)
+	}
+
+	var publishedAt time.Time
+	if body.PublishedAt != nil {
+		publishedAt = time.Time(*body.PublishedAt)
+	}
+
+	return articlesim.ArticleInput{
+		Content: *body.Content,
+		Metadata: articlesim.Metadata{
+			Title:       body.Title,
+			SourceURL:   sourceURL,
+			Author:      body.Author,
+			PublishedAt: publishedAt,
+			Tags:        body.Tags,
+		},
+	}, nil
+}
+
+// ModelsArticle returns the article of the API.
+func ModelsArticle(article articlesim.Article) *models.Article {
+	const maxDuplicates = 100
+
+	duplicateIDs := make([]int64, 0, maxDuplicates)
+	for _, id := range article.DuplicateIDs {
+		duplicateIDs = append(duplicateIDs, int64(id))
+	}
+
+	duplicates := make([]*models.Duplicate, 0, len(article.Duplicates))
+	for _, d := range article.Duplicates {
+		duplicates = append(duplicates, &models.Duplicate{
+			ID:        models.ArticleID(int64(d.ID)),
+			Score:     swag.Float64(d.Score),
+			Algorithm: swag.String(d.Algorithm),
+			Threshold: swag.Float64(d.Threshold),
+		})
+	}
+
+	return &models.Article{
+		ID:                  models.ArticleID(int64(article.ID)),
+		Content:             swag.String(article.Content),
+		Title:               article.Metadata.Title,
+		SourceURL:           strfmt.URI(article.Metadata.SourceURL),
+		Author:              article.Metadata.Author,
+		PublishedAt:         modelsDateTime(article.Metadata.PublishedAt),
+		Tags:                article.Metadata.Tags,
+		DuplicateArticleIds: duplicateIDs,
+		Duplicates:          duplicates,
+	}
+}
+
+// modelsDateTime returns nil for the zero time, so the unknown time is absent.
+func modelsDateTime(t time.Time) *strfmt.DateTime {
+	if t.IsZero() {
+		return nil
+	}
+
+	dt := strfmt.DateTime(t)
+
+	return &dt
+}
@@ -0,0 +1,167 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/swag"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/http/models"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+
+	// exportListSeparator separates items of lists in a CSV field.
+	exportListSeparator = ";"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// ContentTypes are media types of the export formats.
+var ContentTypes = map[string]string{
+	FormatNDJSON: "application/x-ndjson",
+	FormatCSV:    "text/csv",
+}
+
+// exportCSVHeader names columns of the CSV export. Duplicate ids, scores, algorithms and thresholds of an article
+// are lists in the same order. Metadata columns follow the duplicates, so columns of exports made before metadata
+// was kept do not move.
+var exportCSVHeader = []string{
+	"id", "content", "is_unique", "duplicate_group_id", "duplicate_ids", "scores", "algorithms", "thresholds",
+	"title", "source_url", "author", "published_at", "tags",
+}
+
+type ArticleExporter interface {
+	ExportArticles(ctx context.Context, fn func(art articlesim.Article) error) error
+}
+
+// Export writes all articles to w in the format, ndjson or csv, ordered by id. It returns the number of written
+// articles. On failure the output is incomplete.
+func Export(ctx context.Context, exporter ArticleExporter, format string, w io.Writer) (int, error) {
+	write, flush, err := newExportWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+
+	err = exporter.ExportArticles(ctx, func(art articlesim.Article) error {
+		if err := write(art); err != nil {
+			return fmt.Errorf("failed to write article=%d: %w", art.ID, err)
+		}
+
+		exported++
+
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	if err := flush(); err != nil {
+		return exported, fmt.Errorf("failed to flush articles: %w", err)
+	}
+
+	return exported, nil
+}
+
+// newExportWriter returns functions writing an article in the format and flushing the written articles.
+func newExportWriter(format string, w io.Writer) (func(art articlesim.Article) error, func() error, error) {
+	switch format {
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buf)
+
+		write := func(art articlesim.Article) error {
+			return encoder.Encode(modelsExportedArticle(art))
+		}
+
+		return write, buf.Flush, nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportCSVHeader); err != nil {
+			return nil, nil, fmt.Errorf("failed to write header: %w", err)
+		}
+
+		write := func(art articlesim.Article) error {
+			return writer.Write(exportCSVRecord(art))
+		}
+		flush := func() error {
+			writer.Flush()
+
+			return writer.Error()
+		}
+
+		return write, flush, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+func modelsExportedArticle(art articlesim.Article) *models.ExportedArticle {
+	article := ModelsArticle(art)
+
+	return &models.ExportedArticle{
+		ID:                  article.ID,
+		Content:             article.Content,
+		Title:               article.Title,
+		SourceURL:           article.SourceURL,
+		Author:              article.Author,
+		PublishedAt:         article.PublishedAt,
+		Tags:                article.Tags,
+		IsUnique:            swag.Bool(art.IsUnique),
+		DuplicateGroupID:    swag.Int64(int64(art.DuplicateGroupID)),
+		DuplicateArticleIds: article.DuplicateArticleIds,
+		Duplicates:          article.Duplicates,
+	}
+}
+
+func exportCSVRecord(art articlesim.Article) []string {
+	ids := make([]string, 0, len(art.Duplicates))
+	scores := make([]string, 0, len(art.Duplicates))
+	algorithms := make([]string, 0, len(art.Duplicates))
+	thresholds := make([]string, 0, len(art.Duplicates))
+
+	for _, d := range art.Duplicates {
+		ids = append(ids, strconv.Itoa(int(d.ID)))
+		scores = append(scores, strconv.FormatFloat(d.Score, 'g', -1, 64))
+		algorithms = append(algorithms, d.Algorithm)
+		thresholds = append(thresholds, strconv.FormatFloat(d.Threshold, 'g', -1, 64))
+	}
+
+	return []string{
+		strconv.Itoa(int(art.ID)),
+		art.Content,
+		strconv.FormatBool(art.IsUnique),
+		strconv.Itoa(int(art.DuplicateGroupID)),
+		strings.Join(ids, exportListSeparator),
+		strings.Join(scores, exportListSeparator),
+		strings.Join(algorithms, exportListSeparator),
+		strings.Join(thresholds, exportListSeparator),
+		art.Metadata.Title,
+		art.Metadata.SourceURL,
+		art.Metadata.Author,
+		exportTime(art.Metadata.PublishedAt),
+		strings.Join(art.Metadata.Tags, exportListSeparator),
+	}
+}
+
+// exportTime formats the time as RFC 3339, the unknown time is empty.
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,164 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/http/models"
+	"github.com/devchallenge/article-similarity/internal/http/restapi/operations"
+)
+
+// importBatchSize is the number of article lines checked for duplicates and stored at once.
+const importBatchSize = 100
+
+type ArticleImporter interface {
+	ImportArticles(ctx context.Context, inputs []articlesim.ArticleInput) ([]articlesim.Article, error)
+}
+
+// importLine is an article line waiting for its batch to be stored. Invalid lines have the error.
+type importLine struct {
+	number int
+	input  articlesim.ArticleInput
+	err    error
+}
+
+// Import reads articles as newline-delimited JSON objects with the content and stores them in batches of lines, so
+// invalid lines are written along with valid ones. The result of every article line is written to w as a JSON line
+// in the order of lines once its batch is stored. Empty lines are skipped. Import stops at the first failed batch,
+// the lines of the batch get the error. When reading fails, lines of the unfinished batch are neither stored nor
+// written, and the last result has the first line which is not imported with the error, so the lines from it can be
+// imported again. It returns the number of stored articles.
+func Import(ctx context.Context, importer ArticleImporter, r io.Reader, w io.Writer) (int, error) {
+	reader := bufio.NewReader(r)
+	encoder := json.NewEncoder(w)
+	lines := make([]importLine, 0, importBatchSize)
+	inputs := make([]articlesim.ArticleInput, 0, importBatchSize)
+	stored := 0
+
+	for number := 1; ; number++ {
+		text, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return stored, notImported(encoder, number, lines, err)
+		}
+
+		if line := bytes.TrimSpace(text); len(line) != 0 {
+			input, perr := parseImportLine(line)
+			lines = append(lines, importLine{number: number, input: input, err: perr})
+
+			if perr == nil {
+				inputs = append(inputs, input)
+			}
+		}
+
+		if len(lines) < importBatchSize && err == nil {
+			continue
+		}
+
+		n, ierr := importBatch(ctx, importer, lines, inputs, encoder)
+		stored += n
+
+		if ierr != nil || err != nil {
+			return stored, ierr
+		}
+
+		lines = lines[:0]
+		inputs = inputs[:0]
+	}
+}
+
+// importBatch stores articles of valid lines and writes results of all lines.
+func importBatch(ctx context.Context, importer ArticleImporter, lines []importLine, inputs []articlesim.ArticleInput,
+	encoder *json.Encoder) (int, error) {
+	var (
+		articles []articlesim.Article
+		err      error
+	)
+
+	if len(inputs) != 0 {
+		articles, err = importer.ImportArticles(ctx, inputs)
+	}
+
+	for _, line := range lines {
+		result := &models.ImportResult{
+			Line:       swag.Int64(int64(line.number)),
+			ID:         0,
+			Duplicates: nil,
+			Error:      "",
+		}
+
+		switch {
+		case line.err != nil:
+			result.Error = line.err.Error()
+		case err != nil:
+			result.Error = "failed to store article"
+		default:
+			article := articles[0]
+			articles = articles[1:]
+			result.ID = models.ArticleID(int64(article.ID))
+			result.Duplicates = ModelsArticle(article).Duplicates
+		}
+
+		if werr := encoder.Encode(result); werr != nil {
+			return 0, fmt.Errorf("failed to write result of line=%d: %w", line.number, werr)
+		}
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to import articles: %w", err)
+	}
+
+	return len(inputs), nil
+}
+
+// notImported writes the result of the first line of the unfinished batch, or of the line failed to read, with
+// the read error. It returns the read error.
+func notImported(encoder *json.Encoder, number int, lines []importLine, err error) error {
+	err = fmt.Errorf("failed to read line=%d: %w", number, err)
+
+	if len(lines) != 0 {
+		number = lines[0].number
+	}
+
+	result := &models.ImportResult{
+		Line:       swag.Int64(int64(number)),
+		ID:         0,
+		Duplicates: nil,
+		Error:      fmt.Sprintf("lines from this one are not imported: %v", err),
+	}
+
+	if werr := encoder.Encode(result); werr != nil {
+		return fmt.Errorf("failed to write result of line=%d: %w", number, werr)
+	}
+
+	return err
+}
+
+// parseImportLine returns the article of the line. The line is validated like the body of POST /articles.
+func parseImportLine(line []byte) (articlesim.ArticleInput, error) {
+	body := operations.PostArticlesBody{
+		Content:     nil,
+		Title:       "",
+		SourceURL:   "",
+		Author:      "",
+		PublishedAt: nil,
+		Tags:        nil,
+	}
+	if err := json.Unmarshal(line, &body); err != nil {
+		return articlesim.ArticleInput{}, fmt.Errorf("invalid article: %w", err)
+	}
+
+	if err := body.Validate(strfmt.Default); err != nil {
+		return articlesim.ArticleInput{}, fmt.Errorf("invalid article: %w", err)
+	}
+
+	return ParseArticle(body)
+}
@@ -2,6 +2,9 @@ package articlesim
 
 import (
 	"errors"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type (
@@ -10,13 +13,149 @@ type (
 )
 
 type Article struct {
-	ID               ArticleID
-	Content          string
-	DuplicateIDs     []ArticleID
+	ID       ArticleID
+	Content  string
+	Metadata Metadata
+	// Tokens are empty for articles stored before tokens were cached, until they are migrated.
+	Tokens       Tokens
+	DuplicateIDs []ArticleID
+	// Duplicates are links to DuplicateIDs with the similarity scores.
+	Duplicates       []Duplicate
 	IsUnique         bool
 	DuplicateGroupID DuplicateGroupID
 }
 
+// Metadata is the provenance of the article. Every field is optional, articles stored before metadata was kept
+// have none.
+type Metadata struct {
+	Title     string
+	SourceURL string
+	Author    string
+	// PublishedAt is zero when the publication time is unknown.
+	PublishedAt time.Time
+	Tags        []string
+}
+
+// Source returns the outlet which published the article, see SourceOf.
+func (m Metadata) Source() string {
+	return SourceOf(m.SourceURL)
+}
+
+// SourceOf returns the outlet of the URL: its lower-cased host without the port and the www prefix. A bare host,
+// e.g. example.com, is accepted too. It is empty for empty or invalid URLs.
+func SourceOf(sourceURL string) string {
+	u, err := url.Parse(sourceURL)
+	if err == nil && u.Host == "" && u.Scheme == "" {
+		u, err = url.Parse("//" + sourceURL)
+	}
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// ArticleInput is the content with the metadata of an article to store.
+type ArticleInput struct {
+	Content  string
+	Metadata Metadata
+}
+
+// ArticleFilter selects articles by their metadata. Zero fields select all articles.
+type ArticleFilter struct {
+	// Source is the outlet returned by SourceOf.
+	Source string
+	Tag    string
+	// PublishedFrom and PublishedTo bound the publication time inclusively. Articles with unknown publication time
+	// are not selected by bounded filters.
+	PublishedFrom time.Time
+	PublishedTo   time.Time
+}
+
+// Selects reports whether the article with the metadata satisfies the filter.
+func (f ArticleFilter) Selects(m Metadata) bool {
+	if f.Source != "" && m.Source() != f.Source {
+		return false
+	}
+
+	if f.Tag != "" && !hasTag(m.Tags, f.Tag) {
+		return false
+	}
+
+	if (!f.PublishedFrom.IsZero() || !f.PublishedTo.IsZero()) && m.PublishedAt.IsZero() {
+		return false
+	}
+
+	if !f.PublishedFrom.IsZero() && m.PublishedAt.Before(f.PublishedFrom) {
+		return false
+	}
+
+	return f.PublishedTo.IsZero() || !m.PublishedAt.After(f.PublishedTo)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Duplicate is a link to a duplicate article with the similarity of both articles, the algorithm and the threshold
+// which found them duplicates. Links stored before scores were kept have zero score and empty algorithm.
+type Duplicate struct {
+	ID        ArticleID
+	Score     float64
+	Algorithm string
+	Threshold float64
+}
+
+// DuplicateIDsOf returns ids of the linked articles keeping the order, nil for no links.
+func DuplicateIDsOf(duplicates []Duplicate) []ArticleID {
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	ids := make([]ArticleID, 0, len(duplicates))
+	for _, d := range duplicates {
+		ids = append(ids, d.ID)
+	}
+
+	return ids
+}
+
+// UnscoredDuplicates returns links to the articles without scores. It is used for links stored before scores
+// were kept.
+func UnscoredDuplicates(ids []ArticleID) []Duplicate {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	duplicates := make([]Duplicate, 0, len(ids))
+	for _, id := range ids {
+		duplicates = append(duplicates, Duplicate{ID: id, Score: 0, Algorithm: "", Threshold: 0})
+	}
+
+	return duplicates
+}
+
+// Tokens are normalized words of the content with its detected language. They are computed once when the article
+// is stored, so stored articles are not normalized again for every comparison.
+type Tokens struct {
+	Words    []string
+	Language string
+}
+
+// IndexedArticle is the new article with its index keys. Articles are stored in bulk with their keys and
+// memberships of their duplicate groups.
+type IndexedArticle struct {
+	Article
+	Keys []uint64
+}
+
 type DuplicateGroup struct {
 	DuplicateGroupID DuplicateGroupID
 	ArticleID        ArticleID
@@ -27,4 +166,73 @@ type DuplicateGroupResp struct {
 	ArticleIDs       []ArticleID
 }
 
-var ErrArticleNotFound = errors.New("article not found")
+// ArticleUpdate is the article with updated content and duplicate links changed by the update.
+type ArticleUpdate struct {
+	Article             Article
+	AddedDuplicateIDs   []ArticleID
+	RemovedDuplicateIDs []ArticleID
+}
+
+// Match is a stored article found similar to a content with the similarity score.
+type Match struct {
+	Article     Article
+	Score       float64
+	IsDuplicate bool
+}
+
+// Comparison explains the similarity of two articles.
+type Comparison struct {
+	ArticleID      ArticleID
+	OtherArticleID ArticleID
+	// Language and OtherLanguage are detected languages of the articles contents.
+	Language      string
+	OtherLanguage string
+	// Words and OtherWords are normalized words of the articles contents.
+	Words      []string
+	OtherWords []string
+	// Distance and LevenshteinScore are the word-level Levenshtein distance and similarity. The distance ignores word
+	// weights, the similarity is weighted.
+	Distance         int
+	LevenshteinScore float64
+	// Similarity is computed by the configured metric and compared with the threshold of the languages.
+	Similarity float64
+	Threshold  float64
+	IsSimilar  bool
+	// EditScript turns Words into OtherWords.
+	EditScript []EditOp
+}
+
+// EditOp is a word edit. Position is the index in Words, OtherPosition is the index in OtherWords.
+type EditOp struct {
+	Operation     string
+	Position      int
+	OtherPosition int
+	Word          string
+	OtherWord     string
+}
+
+// ReclusterState is the state of reclustering of stored articles.
+type ReclusterState string
+
+const (
+	ReclusterIdle    ReclusterState = "idle"
+	ReclusterRunning ReclusterState = "running"
+	ReclusterDone    ReclusterState = "done"
+	ReclusterFailed  ReclusterState = "failed"
+)
+
+// ReclusterStatus is the progress of reclustering. Processed articles include articles kept from the interrupted
+// run, reclustered ones are written by this run.
+type ReclusterStatus struct {
+	State         ReclusterState
+	Processed     int
+	Reclustered   int
+	Deleted       int
+	LastArticleID ArticleID
+	Error         string
+}
+
+var (
+	ErrArticleNotFound  = errors.New("article not found")
+	ErrReclusterRunning = errors.New("reclustering is already running")
+)
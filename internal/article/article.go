@@ -2,6 +2,7 @@ package article
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
@@ -9,88 +10,198 @@ import (
 	articlesim "github.com/devchallenge/article-similarity/internal"
 )
 
+// progressArticles is the number of articles after which the progress of migration and reclustering is logged.
+const progressArticles = 1000
+
+var ErrIndexParamsChanged = errors.New("index params changed")
+
 type Similarity interface {
-	IsSimilar(idA int, contentA string, idB int, contentB string) bool
-	Similarity(idA int, contentA string, idB int, contentB string) float64
+	// Tokenize normalizes the content into words and detects its language.
+	Tokenize(content string) articlesim.Tokens
+	// Compare returns the similarity of tokenized contents.
+	Compare(tokensA, tokensB articlesim.Tokens) float64
+	// Threshold returns the similarity from which contents of the languages are duplicates.
+	Threshold(languageA, languageB string) float64
+	// IsDuplicate returns the similarity of tokenized contents and whether it reaches the threshold. The similarity
+	// of contents which are not duplicates may be not computed.
+	IsDuplicate(tokensA, tokensB articlesim.Tokens) (float64, bool)
+	Explain(tokensA, tokensB articlesim.Tokens) articlesim.Comparison
+	// Algorithm returns the name of the similarity algorithm.
+	Algorithm() string
+}
+
+// Index computes keys of locality-sensitive hashing. Articles sharing a key are candidates to be duplicates.
+type Index interface {
+	Keys(words []string) []uint64
+	// Params returns the parameters of the index. Keys computed with different parameters do not match.
+	Params() string
 }
 
 type Storage interface {
+	// WithTransaction runs fn atomically and isolated from other transactions. Methods called by fn must get
+	// the context passed to fn.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	NextArticleID(ctx context.Context) (articlesim.ArticleID, error)
-	CreateArticle(ctx context.Context, id articlesim.ArticleID, content string, duplicateIDs []articlesim.ArticleID,
+	// CreateArticle stores the article with links to its duplicates. Duplicate ids of the article are ids
+	// of the links.
+	CreateArticle(ctx context.Context, id articlesim.ArticleID, content string, metadata articlesim.Metadata,
+		tokens articlesim.Tokens, duplicates []articlesim.Duplicate, isUnique bool,
+		duplicateGroupID articlesim.DuplicateGroupID) error
+	// CreateArticles stores the new articles with their index keys and memberships of their duplicate groups in bulk.
+	CreateArticles(ctx context.Context, articles []articlesim.IndexedArticle) error
+	// UpdateArticle replaces links to duplicates of the article.
+	UpdateArticle(ctx context.Context, id articlesim.ArticleID, duplicates []articlesim.Duplicate) error
+	// RegroupArticle replaces links to duplicates of the article and moves it to the duplicate group.
+	RegroupArticle(ctx context.Context, id articlesim.ArticleID, duplicates []articlesim.Duplicate,
 		isUnique bool, duplicateGroupID articlesim.DuplicateGroupID) error
-	UpdateArticle(ctx context.Context, id articlesim.ArticleID, duplicateIDs []articlesim.ArticleID) error
+	// DeleteArticle removes the article with its duplicate group membership and index keys.
+	DeleteArticle(ctx context.Context, id articlesim.ArticleID) error
 	ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error)
-	AllArticles(ctx context.Context) ([]articlesim.Article, error)
-	UniqueArticles(ctx context.Context) ([]articlesim.Article, error)
+	// ArticlesByDuplicateGroup returns articles of the duplicate group ordered by id.
+	ArticlesByDuplicateGroup(ctx context.Context,
+		duplicateGroupID articlesim.DuplicateGroupID) ([]articlesim.Article, error)
+	// ForEachArticle calls fn for every article ordered by id. Iteration stops at the first error.
+	ForEachArticle(ctx context.Context, fn func(art articlesim.Article) error) error
+	// SnapshotArticles calls fn for every article stored when the iteration starts ordered by id, so articles
+	// created during the iteration are not seen. Iteration stops at the first error.
+	SnapshotArticles(ctx context.Context, fn func(art articlesim.Article) error) error
+	// UniqueArticles returns at most limit unique articles selected by the filter with id greater than after
+	// ordered by id.
+	UniqueArticles(ctx context.Context, filter articlesim.ArticleFilter, after articlesim.ArticleID,
+		limit int) ([]articlesim.Article, error)
 	NextDuplicateGroupID(ctx context.Context) (articlesim.DuplicateGroupID, error)
 	CreateDuplicateGroup(ctx context.Context, duplicateGroupID articlesim.DuplicateGroupID,
 		articleID articlesim.ArticleID) error
-	AllDuplicateGroups(ctx context.Context) ([]articlesim.DuplicateGroup, error)
+	// DuplicateGroups returns at most limit groups having several articles with id greater than after
+	// ordered by id. Article ids of a group are ordered too.
+	DuplicateGroups(ctx context.Context, after articlesim.DuplicateGroupID,
+		limit int) ([]articlesim.DuplicateGroupResp, error)
+	IndexArticle(ctx context.Context, id articlesim.ArticleID, keys []uint64) error
+	// ReindexArticle replaces tokens and index keys of the article.
+	ReindexArticle(ctx context.Context, id articlesim.ArticleID, tokens articlesim.Tokens, keys []uint64) error
+	// IndexParams returns the parameters of the index which computed the stored index keys, empty when they are
+	// not recorded.
+	IndexParams(ctx context.Context) (string, error)
+	// SetIndexParams records the parameters of the index which computed the stored index keys.
+	SetIndexParams(ctx context.Context, params string) error
+	CandidateArticles(ctx context.Context, keys []uint64) ([]articlesim.Article, error)
+	// ChangeSequence returns the sequence of the last creation, update or deletion of an article.
+	ChangeSequence(ctx context.Context) (int64, error)
+	// ChangedArticleIDs returns ids of articles created, updated or deleted after the change sequence ordered by id.
+	ChangedArticleIDs(ctx context.Context, after int64) ([]articlesim.ArticleID, error)
+	MergeDuplicateGroups(ctx context.Context, duplicateGroupID articlesim.DuplicateGroupID,
+		mergedGroupIDs []articlesim.DuplicateGroupID) error
 }
 
 type Service struct {
 	similar Similarity
+	index   Index
 	storage Storage
 }
 
-func New(similar Similarity, storage Storage) *Service {
+func New(similar Similarity, index Index, storage Storage) *Service {
 	return &Service{
 		similar: similar,
+		index:   index,
 		storage: storage,
 	}
 }
 
-func (a *Service) CreateArticle(ctx context.Context, content string) (articlesim.Article, error) {
-	id, err := a.storage.NextArticleID(ctx)
+// CreateArticle stores the article with its metadata and duplicates in the transaction, so concurrently created
+// duplicates are found by each other and join the same group.
+func (a *Service) CreateArticle(ctx context.Context, content string,
+	metadata articlesim.Metadata) (articlesim.Article, error) {
+	var article articlesim.Article
+
+	err := a.storage.WithTransaction(ctx, func(ctx context.Context) error {
+		id, err := a.storage.NextArticleID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get next article id: %w", err)
+		}
+
+		article, err = a.createArticle(ctx, a.storage, id, content, metadata)
+
+		return err
+	})
 	if err != nil {
-		return articlesim.Article{}, fmt.Errorf("failed to get next article id: %w", err)
+		return articlesim.Article{}, err
 	}
 
-	duplicateIDs, duplicateGroupID, err := a.duplicateArticleIDsWithDuplicateGroupID(ctx, id, content)
+	return article, nil
+}
+
+// createArticle finds duplicates of the article in the storage and creates it there. The storage is the storage of
+// the service or the batch of the import buffering the created articles.
+func (a *Service) createArticle(ctx context.Context, storage Storage, id articlesim.ArticleID, content string,
+	metadata articlesim.Metadata) (articlesim.Article, error) {
+	tokens := a.similar.Tokenize(content)
+	keys := a.index.Keys(tokens.Words)
+
+	duplicates, duplicateGroupID, mergedGroupIDs, err := a.duplicatesWithDuplicateGroupID(ctx, storage, tokens, keys)
 	if err != nil {
-		log.Printf("failed to find duplicate articles ids: %v", err)
+		return articlesim.Article{}, fmt.Errorf("failed to find duplicate articles ids: %w", err)
 	}
 
-	isUnique := len(duplicateIDs) == 0
-	if err := a.storage.CreateArticle(ctx, id, content, duplicateIDs, isUnique, duplicateGroupID); err != nil {
+	isUnique := len(duplicates) == 0
+	if err := storage.CreateArticle(ctx, id, content, metadata, tokens, duplicates, isUnique,
+		duplicateGroupID); err != nil {
 		return articlesim.Article{}, fmt.Errorf("failed to create article: %w", err)
 	}
 
-	if err := a.storage.CreateDuplicateGroup(ctx, duplicateGroupID, id); err != nil {
+	if err := storage.IndexArticle(ctx, id, keys); err != nil {
+		return articlesim.Article{}, fmt.Errorf("failed to index article: %w", err)
+	}
+
+	if err := storage.CreateDuplicateGroup(ctx, duplicateGroupID, id); err != nil {
 		return articlesim.Article{}, fmt.Errorf("failed to create duplicate group: %w", err)
 	}
 
+	if len(mergedGroupIDs) != 0 {
+		if err := storage.MergeDuplicateGroups(ctx, duplicateGroupID, mergedGroupIDs); err != nil {
+			return articlesim.Article{}, fmt.Errorf("failed to merge duplicate groups: %w", err)
+		}
+	}
+
 	if !isUnique {
-		a.updateArticlesWithDuplicateID(ctx, duplicateIDs, id)
+		if err := a.updateArticlesWithDuplicateID(ctx, storage, duplicates, id); err != nil {
+			return articlesim.Article{}, err
+		}
 	}
 
 	return articlesim.Article{
 		ID:               id,
 		Content:          content,
-		DuplicateIDs:     duplicateIDs,
+		Metadata:         metadata,
+		Tokens:           tokens,
+		DuplicateIDs:     articlesim.DuplicateIDsOf(duplicates),
+		Duplicates:       duplicates,
 		IsUnique:         isUnique,
 		DuplicateGroupID: duplicateGroupID,
 	}, nil
 }
 
-func (a *Service) updateArticlesWithDuplicateID(ctx context.Context, duplicateIDs []articlesim.ArticleID,
-	id articlesim.ArticleID) {
-	for _, did := range duplicateIDs {
-		art, err := a.storage.ArticleByID(ctx, did)
+// updateArticlesWithDuplicateID links duplicates of the new article to it with the same scores.
+func (a *Service) updateArticlesWithDuplicateID(ctx context.Context, storage Storage,
+	duplicates []articlesim.Duplicate, id articlesim.ArticleID) error {
+	for _, d := range duplicates {
+		art, err := storage.ArticleByID(ctx, d.ID)
 		if err != nil {
-			log.Printf("failed to get article by id=%d: %v", did, err)
-
-			continue
+			return fmt.Errorf("failed to get article by id=%d: %w", d.ID, err)
 		}
 
 		if art.IsUnique {
 			continue
 		}
 
-		if err := a.storage.UpdateArticle(ctx, art.ID, append(art.DuplicateIDs, id)); err != nil {
-			log.Printf("failed to update article=%d: %v", art.ID, err)
+		link := d
+		link.ID = id
+
+		if err := storage.UpdateArticle(ctx, art.ID, append(art.Duplicates, link)); err != nil {
+			return fmt.Errorf("failed to update article=%d: %w", art.ID, err)
 		}
 	}
+
+	return nil
 }
 
 func (a *Service) ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error) {
@@ -102,77 +213,527 @@ func (a *Service) ArticleByID(ctx context.Context, id articlesim.ArticleID) (art
 	return article, nil
 }
 
-func (a *Service) UniqueArticles(ctx context.Context) ([]articlesim.Article, error) {
-	articles, err := a.storage.UniqueArticles(ctx)
+// UpdateArticle replaces the content of the article keeping its metadata and re-evaluates its duplicates like for
+// a new article.
+// The article is detached from its duplicate group as if it was deleted and attached to the groups of its new
+// duplicates. Returned duplicate ids are changed in both directions.
+func (a *Service) UpdateArticle(ctx context.Context, id articlesim.ArticleID,
+	content string) (articlesim.ArticleUpdate, error) {
+	var update articlesim.ArticleUpdate
+
+	err := a.storage.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		update, err = a.updateArticle(ctx, id, content)
+
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get unique articles: %w", err)
+		return articlesim.ArticleUpdate{}, err
 	}
 
-	return articles, nil
+	return update, nil
 }
 
-func (a *Service) DuplicateGroups(ctx context.Context) ([]articlesim.DuplicateGroupResp, error) {
-	groups, err := a.storage.AllDuplicateGroups(ctx)
+func (a *Service) updateArticle(ctx context.Context, id articlesim.ArticleID,
+	content string) (articlesim.ArticleUpdate, error) {
+	art, err := a.storage.ArticleByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all duplicate groups: %w", err)
+		return articlesim.ArticleUpdate{}, fmt.Errorf("failed to get article from storage: %w", err)
 	}
 
-	duplMap := make(map[articlesim.DuplicateGroupID][]articlesim.ArticleID, len(groups))
-	for _, g := range groups {
-		duplMap[g.DuplicateGroupID] = append(duplMap[g.DuplicateGroupID], g.ArticleID)
+	articles, err := a.detachArticle(ctx, art)
+	if err != nil {
+		return articlesim.ArticleUpdate{}, err
 	}
 
-	duplWithOnes := make([]articlesim.DuplicateGroupResp, 0, len(duplMap))
-	for gid, ids := range duplMap {
-		duplWithOnes = append(duplWithOnes, articlesim.DuplicateGroupResp{
-			DuplicateGroupID: gid,
-			ArticleIDs:       ids,
-		})
+	updated, err := a.createArticle(ctx, a.storage, id, content, art.Metadata)
+	if err != nil {
+		return articlesim.ArticleUpdate{}, err
 	}
 
-	duplicates := make([]articlesim.DuplicateGroupResp, 0, len(duplWithOnes))
+	previousIDs := linkedIDs(art, articles)
 
-	for _, d := range duplWithOnes {
-		if len(d.ArticleIDs) <= 1 {
-			continue
+	return articlesim.ArticleUpdate{
+		Article:             updated,
+		AddedDuplicateIDs:   subtractIDs(updated.DuplicateIDs, previousIDs),
+		RemovedDuplicateIDs: subtractIDs(previousIDs, updated.DuplicateIDs),
+	}, nil
+}
+
+// DeleteArticle removes the article and repairs its duplicate group. The article is removed from duplicates of
+// other articles and the group is split when the article was the only link between its duplicates.
+func (a *Service) DeleteArticle(ctx context.Context, id articlesim.ArticleID) error {
+	return a.storage.WithTransaction(ctx, func(ctx context.Context) error {
+		art, err := a.storage.ArticleByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get article from storage: %w", err)
 		}
 
-		duplicates = append(duplicates, d)
+		_, err = a.detachArticle(ctx, art)
+
+		return err
+	})
+}
+
+// detachArticle deletes the article and regroups the rest of its duplicate group. It returns articles of the group
+// before the article was deleted.
+func (a *Service) detachArticle(ctx context.Context, art articlesim.Article) ([]articlesim.Article, error) {
+	articles, err := a.storage.ArticlesByDuplicateGroup(ctx, art.DuplicateGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get articles of duplicate group=%d: %w", art.DuplicateGroupID, err)
+	}
+
+	if err := a.storage.DeleteArticle(ctx, art.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete article: %w", err)
+	}
+
+	if err := a.regroup(ctx, art.DuplicateGroupID, articles, art.ID); err != nil {
+		return nil, fmt.Errorf("failed to regroup duplicate group=%d: %w", art.DuplicateGroupID, err)
+	}
+
+	return articles, nil
+}
+
+// Search returns at most limit stored articles most similar to the content ordered by descending score.
+// It verifies the same candidates as CreateArticle, but the content is not stored.
+func (a *Service) Search(ctx context.Context, content string, limit int) ([]articlesim.Match, error) {
+	tokens := a.similar.Tokenize(content)
+
+	matches, err := a.matches(ctx, tokens, a.index.Keys(tokens.Words))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// Compare explains the similarity of the article to the other article.
+func (a *Service) Compare(ctx context.Context, id, otherID articlesim.ArticleID) (articlesim.Comparison, error) {
+	art, err := a.storage.ArticleByID(ctx, id)
+	if err != nil {
+		return articlesim.Comparison{}, fmt.Errorf("failed to get article=%d from storage: %w", id, err)
+	}
+
+	other, err := a.storage.ArticleByID(ctx, otherID)
+	if err != nil {
+		return articlesim.Comparison{}, fmt.Errorf("failed to get article=%d from storage: %w", otherID, err)
 	}
 
-	sort.Slice(duplicates, func(i, j int) bool {
-		return duplicates[i].DuplicateGroupID < duplicates[j].DuplicateGroupID
+	comparison := a.similar.Explain(a.tokens(art), a.tokens(other))
+	comparison.ArticleID = art.ID
+	comparison.OtherArticleID = other.ID
+
+	return comparison, nil
+}
+
+// MigrateTokens tokenizes every stored article and replaces its tokens and index keys. It backfills tokens of
+// articles stored before tokens were cached and must be run again when the normalization or the index params are
+// changed, otherwise new articles are compared with tokens of the old normalization. The index params are recorded
+// when all articles are migrated. Every article is migrated in its own transaction,
+// so the migration runs along with the server and may be interrupted and restarted. It returns the number of
+// migrated articles.
+func (a *Service) MigrateTokens(ctx context.Context) (int, error) {
+	migrated := 0
+
+	err := a.storage.ForEachArticle(ctx, func(art articlesim.Article) error {
+		err := a.storage.WithTransaction(ctx, func(ctx context.Context) error {
+			// The article is read again, as it may be updated or deleted since the iteration started.
+			art, err := a.storage.ArticleByID(ctx, art.ID)
+			if errors.Is(err, articlesim.ErrArticleNotFound) {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to get article from storage: %w", err)
+			}
+
+			tokens := a.similar.Tokenize(art.Content)
+
+			return a.storage.ReindexArticle(ctx, art.ID, tokens, a.index.Keys(tokens.Words))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to migrate article=%d: %w", art.ID, err)
+		}
+
+		migrated++
+
+		if migrated%progressArticles == 0 {
+			log.Printf("migrated tokens of %d articles", migrated)
+		}
+
+		return nil
 	})
+	if err != nil {
+		return migrated, err
+	}
+
+	if err := a.storage.SetIndexParams(ctx, a.index.Params()); err != nil {
+		return migrated, fmt.Errorf("failed to set index params: %w", err)
+	}
 
-	return duplicates, nil
+	return migrated, nil
 }
 
-func (a *Service) duplicateArticleIDsWithDuplicateGroupID(ctx context.Context, id articlesim.ArticleID, content string,
-) ([]articlesim.ArticleID, articlesim.DuplicateGroupID, error) {
-	articles, err := a.storage.AllArticles(ctx)
+// EnsureIndex checks that the stored index keys are computed with the params of the index. When the params are
+// not recorded, the stored articles may have no keys, e.g. they are stored before the index was introduced, so
+// they are migrated first. Keys computed with other params are never shared with new articles, so it fails until
+// the articles are migrated with the current params.
+func (a *Service) EnsureIndex(ctx context.Context) error {
+	params, err := a.storage.IndexParams(ctx)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get all articles: %w", err)
+		return fmt.Errorf("failed to get index params: %w", err)
 	}
 
-	duplicates := make([]articlesim.ArticleID, 0, len(articles))
+	switch params {
+	case a.index.Params():
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("%w: stored keys are computed with %s, configured %s", ErrIndexParamsChanged, params,
+			a.index.Params())
+	}
+
+	migrated, err := a.MigrateTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to index stored articles: %w", err)
+	}
+
+	log.Printf("indexed %d articles stored without index params", migrated)
+
+	return nil
+}
+
+// UniqueArticles returns a page of unique articles selected by the filter following the cursor and the cursor
+// of the next page. Zero cursor is the first page, zero next cursor means the last page.
+func (a *Service) UniqueArticles(ctx context.Context, filter articlesim.ArticleFilter, cursor articlesim.ArticleID,
+	limit int) ([]articlesim.Article, articlesim.ArticleID, error) {
+	articles, err := a.storage.UniqueArticles(ctx, filter, cursor, limit+1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get unique articles: %w", err)
+	}
+
+	if len(articles) <= limit {
+		return articles, 0, nil
+	}
+
+	articles = articles[:limit]
+
+	return articles, articles[limit-1].ID, nil
+}
+
+// DuplicateGroups returns a page of duplicate groups following the cursor and the cursor of the next page.
+// Zero cursor is the first page, zero next cursor means the last page.
+func (a *Service) DuplicateGroups(ctx context.Context, cursor articlesim.DuplicateGroupID,
+	limit int) ([]articlesim.DuplicateGroupResp, articlesim.DuplicateGroupID, error) {
+	groups, err := a.storage.DuplicateGroups(ctx, cursor, limit+1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get duplicate groups: %w", err)
+	}
+
+	if len(groups) <= limit {
+		return groups, 0, nil
+	}
+
+	groups = groups[:limit]
+
+	return groups, groups[limit-1].DuplicateGroupID, nil
+}
+
+// ExportArticles calls fn for every article stored when the export starts in id order.
+func (a *Service) ExportArticles(ctx context.Context, fn func(art articlesim.Article) error) error {
+	if err := a.storage.SnapshotArticles(ctx, fn); err != nil {
+		return fmt.Errorf("failed to export articles: %w", err)
+	}
+
+	return nil
+}
+
+// duplicatesWithDuplicateGroupID verifies only candidate articles found by the index keys instead of comparing
+// the content with every stored article. It returns links to the duplicates with their scores.
+//
+// Duplicates may belong to different groups when the content bridges them. In that case the groups are united:
+// the group with the smallest id is returned as the duplicate group id and the others are returned as merged.
+func (a *Service) duplicatesWithDuplicateGroupID(ctx context.Context, storage Storage, tokens articlesim.Tokens,
+	keys []uint64) ([]articlesim.Duplicate, articlesim.DuplicateGroupID, []articlesim.DuplicateGroupID, error) {
+	matches, err := a.duplicates(ctx, storage, tokens, keys)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	duplicates := make([]articlesim.Duplicate, 0, len(matches))
+	groups := make(map[articlesim.DuplicateGroupID]struct{})
 
 	var duplicateGroupID articlesim.DuplicateGroupID
 
-	for _, article := range articles {
-		if a.similar.IsSimilar(int(id), content, int(article.ID), article.Content) {
-			duplicates = append(duplicates, article.ID)
+	for _, m := range matches {
+		article := m.Article
+
+		duplicates = append(duplicates, articlesim.Duplicate{
+			ID:        article.ID,
+			Score:     m.Score,
+			Algorithm: a.similar.Algorithm(),
+			Threshold: a.similar.Threshold(tokens.Language, a.tokens(article).Language),
+		})
+		groups[article.DuplicateGroupID] = struct{}{}
+
+		if duplicateGroupID == 0 || article.DuplicateGroupID < duplicateGroupID {
 			duplicateGroupID = article.DuplicateGroupID
 		}
 	}
 
 	if duplicateGroupID == 0 {
-		gid, err := a.storage.NextDuplicateGroupID(ctx)
+		gid, err := storage.NextDuplicateGroupID(ctx)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get next duplicate group id: %w", err)
+			return nil, 0, nil, fmt.Errorf("failed to get next duplicate group id: %w", err)
 		}
 
-		return nil, gid, nil
+		return nil, gid, nil, nil
+	}
+
+	merged := make([]articlesim.DuplicateGroupID, 0, len(groups)-1)
+
+	for gid := range groups {
+		if gid != duplicateGroupID {
+			merged = append(merged, gid)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i] < merged[j]
+	})
+
+	return duplicates, duplicateGroupID, merged, nil
+}
+
+// matches verifies candidate articles sharing the index keys and returns them with similarity scores ordered by
+// article id. Candidates are compared by their cached tokens, so only the tokens of the content are normalized.
+func (a *Service) matches(ctx context.Context, tokens articlesim.Tokens,
+	keys []uint64) ([]articlesim.Match, error) {
+	articles, err := a.storage.CandidateArticles(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candidate articles: %w", err)
+	}
+
+	matches := make([]articlesim.Match, 0, len(articles))
+
+	for _, article := range articles {
+		candidate := a.tokens(article)
+		score := a.similar.Compare(tokens, candidate)
+
+		matches = append(matches, articlesim.Match{
+			Article:     article,
+			Score:       score,
+			IsDuplicate: score >= a.similar.Threshold(tokens.Language, candidate.Language),
+		})
+	}
+
+	return matches, nil
+}
+
+// duplicates returns candidate articles sharing the index keys which are duplicates of the tokens ordered by article
+// id. Only the threshold is checked, so candidates which are not duplicates are rejected before their scores are
+// computed.
+func (a *Service) duplicates(ctx context.Context, storage Storage, tokens articlesim.Tokens,
+	keys []uint64) ([]articlesim.Match, error) {
+	articles, err := storage.CandidateArticles(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candidate articles: %w", err)
+	}
+
+	matches := make([]articlesim.Match, 0)
+
+	for _, article := range articles {
+		score, ok := a.similar.IsDuplicate(tokens, a.tokens(article))
+		if !ok {
+			continue
+		}
+
+		matches = append(matches, articlesim.Match{
+			Article:     article,
+			Score:       score,
+			IsDuplicate: true,
+		})
+	}
+
+	return matches, nil
+}
+
+// tokens returns the cached tokens of the article. Articles stored before tokens were cached are tokenized
+// on every comparison until MigrateTokens is run.
+func (a *Service) tokens(art articlesim.Article) articlesim.Tokens {
+	if art.Tokens.Language == "" {
+		return a.similar.Tokenize(art.Content)
+	}
+
+	return art.Tokens
+}
+
+// regroup splits articles of the duplicate group without the removed article into connected components of
+// duplicate links. The first component keeps the group id, others get new ones. The oldest article of a component
+// becomes unique without duplicates like a newly created one, so its links are kept by the linked articles.
+func (a *Service) regroup(ctx context.Context, duplicateGroupID articlesim.DuplicateGroupID,
+	articles []articlesim.Article, removedID articlesim.ArticleID) error {
+	for i, component := range duplicateComponents(articles, removedID) {
+		gid := duplicateGroupID
+
+		if i > 0 {
+			var err error
+			if gid, err = a.storage.NextDuplicateGroupID(ctx); err != nil {
+				return fmt.Errorf("failed to get next duplicate group id: %w", err)
+			}
+		}
+
+		unique := component[0]
+		uniqueLinks := make(map[articlesim.ArticleID]articlesim.Duplicate, len(unique.Duplicates))
+
+		for _, d := range unique.Duplicates {
+			uniqueLinks[d.ID] = d
+		}
+
+		for j, art := range component {
+			isUnique := j == 0
+
+			var duplicates []articlesim.Duplicate
+
+			if !isUnique {
+				duplicates = subtractDuplicates(art.Duplicates, removedID)
+
+				if link, ok := uniqueLinks[art.ID]; ok && !containsID(articlesim.DuplicateIDsOf(duplicates), unique.ID) {
+					link.ID = unique.ID
+					duplicates = append(duplicates, link)
+				}
+			}
+
+			if err := a.storage.RegroupArticle(ctx, art.ID, duplicates, isUnique, gid); err != nil {
+				return fmt.Errorf("failed to regroup article=%d: %w", art.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// duplicateComponents returns connected components of articles linked by duplicate ids, skipping the removed
+// article. Articles must be ordered by id, so components and their articles are ordered by id too.
+func duplicateComponents(articles []articlesim.Article,
+	removedID articlesim.ArticleID) [][]articlesim.Article {
+	parent := make(map[articlesim.ArticleID]articlesim.ArticleID, len(articles))
+
+	for _, art := range articles {
+		if art.ID != removedID {
+			parent[art.ID] = art.ID
+		}
+	}
+
+	find := func(id articlesim.ArticleID) articlesim.ArticleID {
+		for parent[id] != id {
+			parent[id] = parent[parent[id]]
+			id = parent[id]
+		}
+
+		return id
+	}
+
+	for _, art := range articles {
+		if art.ID == removedID {
+			continue
+		}
+
+		for _, did := range art.DuplicateIDs {
+			if _, ok := parent[did]; !ok {
+				continue
+			}
+
+			// The smallest id is the root, so it is the first article of the component.
+			rootA, rootB := find(art.ID), find(did)
+			if rootA < rootB {
+				parent[rootB] = rootA
+			} else {
+				parent[rootA] = rootB
+			}
+		}
+	}
+
+	index := make(map[articlesim.ArticleID]int)
+	components := make([][]articlesim.Article, 0)
+
+	for _, art := range articles {
+		if art.ID == removedID {
+			continue
+		}
+
+		root := find(art.ID)
+
+		i, ok := index[root]
+		if !ok {
+			i = len(components)
+			index[root] = i
+			components = append(components, nil)
+		}
+
+		components[i] = append(components[i], art)
+	}
+
+	return components
+}
+
+// linkedIDs returns ids of articles linked to the article in both directions ordered by id.
+func linkedIDs(art articlesim.Article, articles []articlesim.Article) []articlesim.ArticleID {
+	ids := subtractIDs(art.DuplicateIDs, nil)
+
+	for _, other := range articles {
+		if other.ID != art.ID && containsID(other.DuplicateIDs, art.ID) && !containsID(ids, other.ID) {
+			ids = append(ids, other.ID)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return ids
+}
+
+// subtractIDs returns ids which are not in the subtracted ones keeping the order.
+func subtractIDs(ids, subtracted []articlesim.ArticleID) []articlesim.ArticleID {
+	res := make([]articlesim.ArticleID, 0, len(ids))
+
+	for _, id := range ids {
+		if !containsID(subtracted, id) {
+			res = append(res, id)
+		}
+	}
+
+	return res
+}
+
+// subtractDuplicates returns links to articles except the removed one keeping the order.
+func subtractDuplicates(duplicates []articlesim.Duplicate, removedID articlesim.ArticleID) []articlesim.Duplicate {
+	res := make([]articlesim.Duplicate, 0, len(duplicates))
+
+	for _, d := range duplicates {
+		if d.ID != removedID {
+			res = append(res, d)
+		}
+	}
+
+	return res
+}
+
+func containsID(ids []articlesim.ArticleID, id articlesim.ArticleID) bool {
+	for _, did := range ids {
+		if did == id {
+			return true
+		}
 	}
 
-	return duplicates, duplicateGroupID, nil
+	return false
 }
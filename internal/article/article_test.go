@@ -0,0 +1,902 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/file"
+	"github.com/devchallenge/article-similarity/internal/memory"
+)
+
+// wordSimilarity scores contents by the share of common words. Short contents sharing a word are similar.
+type wordSimilarity struct{}
+
+func (s wordSimilarity) Tokenize(content string) articlesim.Tokens {
+	return articlesim.Tokens{
+		Words:    strings.Fields(content),
+		Language: "en",
+	}
+}
+
+func (s wordSimilarity) Compare(tokensA, tokensB articlesim.Tokens) float64 {
+	words := make(map[string]bool)
+	for _, w := range tokensA.Words {
+		words[w] = false
+	}
+
+	common := 0
+
+	for _, w := range tokensB.Words {
+		if shared, ok := words[w]; ok && !shared {
+			words[w] = true
+			common++
+		} else if !ok {
+			words[w] = false
+		}
+	}
+
+	if len(words) == 0 {
+		return 1
+	}
+
+	return float64(common) / float64(len(words))
+}
+
+func (s wordSimilarity) Threshold(languageA, languageB string) float64 {
+	return 0.1
+}
+
+func (s wordSimilarity) IsDuplicate(tokensA, tokensB articlesim.Tokens) (float64, bool) {
+	score := s.Compare(tokensA, tokensB)
+
+	return score, score >= s.Threshold(tokensA.Language, tokensB.Language)
+}
+
+func (s wordSimilarity) Algorithm() string {
+	return "words"
+}
+
+func (s wordSimilarity) Explain(tokensA, tokensB articlesim.Tokens) articlesim.Comparison {
+	return articlesim.Comparison{
+		ArticleID:        0,
+		OtherArticleID:   0,
+		Language:         tokensA.Language,
+		OtherLanguage:    tokensB.Language,
+		Words:            tokensA.Words,
+		OtherWords:       tokensB.Words,
+		Distance:         0,
+		LevenshteinScore: 0,
+		Similarity:       s.Compare(tokensA, tokensB),
+		Threshold:        s.Threshold(tokensA.Language, tokensB.Language),
+		IsSimilar:        s.Compare(tokensA, tokensB) >= s.Threshold(tokensA.Language, tokensB.Language),
+		EditScript:       nil,
+	}
+}
+
+// stored returns the article as it is stored: with tokens of its content and links to its duplicates scored by
+// contents of the articles.
+func stored(art articlesim.Article, articles []articlesim.Article) articlesim.Article {
+	similar := wordSimilarity{}
+	art.Tokens = similar.Tokenize(art.Content)
+
+	for _, did := range art.DuplicateIDs {
+		for _, other := range articles {
+			if other.ID != did {
+				continue
+			}
+
+			otherTokens := similar.Tokenize(other.Content)
+			art.Duplicates = append(art.Duplicates, articlesim.Duplicate{
+				ID:        did,
+				Score:     similar.Compare(art.Tokens, otherTokens),
+				Algorithm: similar.Algorithm(),
+				Threshold: similar.Threshold(art.Tokens.Language, otherTokens.Language),
+			})
+		}
+	}
+
+	return art
+}
+
+// singleKeyIndex makes every article a candidate duplicate.
+type singleKeyIndex struct{}
+
+func (i singleKeyIndex) Keys(words []string) []uint64 {
+	return []uint64{1}
+}
+
+func (i singleKeyIndex) Params() string {
+	return "single"
+}
+
+// otherKeyIndex computes keys with other params than singleKeyIndex.
+type otherKeyIndex struct {
+	singleKeyIndex
+}
+
+func (i otherKeyIndex) Params() string {
+	return "other"
+}
+
+// yieldingStorage switches goroutines after candidates are read, so concurrent writers without isolation decide on
+// duplicates from the same stale candidates.
+type yieldingStorage struct {
+	Storage
+}
+
+func (s yieldingStorage) CandidateArticles(ctx context.Context, keys []uint64) ([]articlesim.Article, error) {
+	articles, err := s.Storage.CandidateArticles(ctx, keys)
+
+	runtime.Gosched()
+
+	return articles, err
+}
+
+// failingCandidatesStorage fails to read candidates after the article id is taken.
+type failingCandidatesStorage struct {
+	Storage
+	err error
+}
+
+func (s failingCandidatesStorage) CandidateArticles(ctx context.Context, keys []uint64) ([]articlesim.Article, error) {
+	return nil, s.err
+}
+
+func newService(t *testing.T, contents ...string) *Service {
+	t.Helper()
+
+	s := New(wordSimilarity{}, singleKeyIndex{}, memory.New())
+
+	for _, content := range contents {
+		_, err := s.CreateArticle(context.Background(), content, articlesim.Metadata{})
+		require.NoError(t, err)
+	}
+
+	return s
+}
+
+func TestService_CreateArticle(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents []string
+		expected []articlesim.Article
+	}{
+		"when unique contents": {
+			contents: []string{"a", "b"},
+			expected: []articlesim.Article{
+				{ID: 1, Content: "a", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 2, Content: "b", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 2},
+			},
+		},
+		"when duplicate content": {
+			contents: []string{"a", "a b", "a c"},
+			expected: []articlesim.Article{
+				{ID: 1, Content: "a", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 2, Content: "a b", DuplicateIDs: []articlesim.ArticleID{1, 3}, IsUnique: false, DuplicateGroupID: 1},
+				{ID: 3, Content: "a c", DuplicateIDs: []articlesim.ArticleID{1, 2}, IsUnique: false, DuplicateGroupID: 1},
+			},
+		},
+		"when content bridges duplicate groups": {
+			contents: []string{"a", "b", "a b"},
+			expected: []articlesim.Article{
+				{ID: 1, Content: "a", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 2, Content: "b", DuplicateIDs: []articlesim.ArticleID{3}, IsUnique: false, DuplicateGroupID: 1},
+				{ID: 3, Content: "a b", DuplicateIDs: []articlesim.ArticleID{1, 2}, IsUnique: false, DuplicateGroupID: 1},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := newService(t, tc.contents...)
+
+			for _, expected := range tc.expected {
+				art, err := s.ArticleByID(context.Background(), expected.ID)
+
+				require.NoError(t, err)
+				assert.Equal(t, stored(expected, tc.expected), art)
+			}
+		})
+	}
+}
+
+func TestService_CreateArticle_Concurrent(t *testing.T) {
+	const posts = 20
+
+	for name, newStorage := range map[string]func(t *testing.T) Storage{
+		"when memory storage": func(t *testing.T) Storage {
+			return memory.New()
+		},
+		"when file storage": func(t *testing.T) Storage {
+			st, err := file.Open(t.TempDir())
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, st.Close()) })
+
+			return st
+		},
+	} {
+		newStorage := newStorage
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := New(wordSimilarity{}, singleKeyIndex{}, yieldingStorage{Storage: newStorage(t)})
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < posts; i++ {
+				wg.Add(1)
+
+				go func(i int) {
+					defer wg.Done()
+
+					_, err := s.CreateArticle(ctx, fmt.Sprintf("breaking news %d", i), articlesim.Metadata{})
+					assert.NoError(t, err)
+				}(i)
+			}
+
+			wg.Wait()
+
+			groups, _, err := s.DuplicateGroups(ctx, 0, posts)
+			require.NoError(t, err)
+			require.Len(t, groups, 1)
+			assert.Len(t, groups[0].ArticleIDs, posts)
+
+			unique, _, err := s.UniqueArticles(ctx, articlesim.ArticleFilter{}, 0, posts)
+			require.NoError(t, err)
+			assert.Len(t, unique, 1)
+		})
+	}
+}
+
+func TestService_CreateArticle_DuplicatesLookupFails(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	st := memory.New()
+	s := New(wordSimilarity{}, singleKeyIndex{}, failingCandidatesStorage{Storage: st, err: errFailed})
+
+	_, err := s.CreateArticle(ctx, "a", articlesim.Metadata{})
+	assert.True(t, errors.Is(err, errFailed))
+
+	_, err = st.ArticleByID(ctx, 1)
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+
+	id, err := st.NextArticleID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(1), id)
+}
+
+func TestService_ArticleByID_NotFound(t *testing.T) {
+	s := newService(t, "a")
+
+	_, err := s.ArticleByID(context.Background(), 2)
+
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+}
+
+func TestService_DeleteArticle(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents       []string
+		id             articlesim.ArticleID
+		expected       []articlesim.Article
+		expectedGroups []articlesim.DuplicateGroupResp
+	}{
+		"when unique article": {
+			contents: []string{"a", "b"},
+			id:       1,
+			expected: []articlesim.Article{
+				{ID: 2, Content: "b", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 2},
+			},
+			expectedGroups: []articlesim.DuplicateGroupResp{},
+		},
+		"when oldest article of duplicate group": {
+			contents: []string{"a", "a b", "a c"},
+			id:       1,
+			expected: []articlesim.Article{
+				{ID: 2, Content: "a b", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 3, Content: "a c", DuplicateIDs: []articlesim.ArticleID{2}, IsUnique: false, DuplicateGroupID: 1},
+			},
+			expectedGroups: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 1, ArticleIDs: []articlesim.ArticleID{2, 3}},
+			},
+		},
+		"when duplicate article": {
+			contents: []string{"a", "a b", "a c"},
+			id:       2,
+			expected: []articlesim.Article{
+				{ID: 1, Content: "a", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 3, Content: "a c", DuplicateIDs: []articlesim.ArticleID{1}, IsUnique: false, DuplicateGroupID: 1},
+			},
+			expectedGroups: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 1, ArticleIDs: []articlesim.ArticleID{1, 3}},
+			},
+		},
+		"when article bridges duplicate groups": {
+			contents: []string{"a", "b", "a b", "b c"},
+			id:       3,
+			expected: []articlesim.Article{
+				{ID: 1, Content: "a", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 2, Content: "b", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 3},
+				{ID: 4, Content: "b c", DuplicateIDs: []articlesim.ArticleID{2}, IsUnique: false, DuplicateGroupID: 3},
+			},
+			expectedGroups: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 3, ArticleIDs: []articlesim.ArticleID{2, 4}},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := newService(t, tc.contents...)
+
+			require.NoError(t, s.DeleteArticle(context.Background(), tc.id))
+
+			_, err := s.ArticleByID(context.Background(), tc.id)
+			assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+
+			for _, expected := range tc.expected {
+				art, err := s.ArticleByID(context.Background(), expected.ID)
+
+				require.NoError(t, err)
+				assert.Equal(t, stored(expected, tc.expected), art)
+			}
+
+			groups, _, err := s.DuplicateGroups(context.Background(), 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedGroups, groups)
+
+			matches, err := s.Search(context.Background(), "a b c", 10)
+			require.NoError(t, err)
+
+			for _, m := range matches {
+				assert.NotEqual(t, tc.id, m.Article.ID)
+			}
+		})
+	}
+}
+
+func TestService_UpdateArticle(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents        []string
+		id              articlesim.ArticleID
+		content         string
+		expectedUpdate  articlesim.ArticleUpdate
+		expectedArticle []articlesim.Article
+		expectedGroups  []articlesim.DuplicateGroupResp
+	}{
+		"when content stays similar": {
+			contents: []string{"a", "a b"},
+			id:       2,
+			content:  "a c",
+			expectedUpdate: articlesim.ArticleUpdate{
+				Article: articlesim.Article{
+					ID: 2, Content: "a c", DuplicateIDs: []articlesim.ArticleID{1}, IsUnique: false, DuplicateGroupID: 1,
+				},
+				AddedDuplicateIDs:   []articlesim.ArticleID{},
+				RemovedDuplicateIDs: []articlesim.ArticleID{},
+			},
+			expectedArticle: []articlesim.Article{
+				{ID: 1, Content: "a", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+			},
+			expectedGroups: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 1, ArticleIDs: []articlesim.ArticleID{1, 2}},
+			},
+		},
+		"when article moves to another duplicate group": {
+			contents: []string{"a", "b", "a c"},
+			id:       3,
+			content:  "b c",
+			expectedUpdate: articlesim.ArticleUpdate{
+				Article: articlesim.Article{
+					ID: 3, Content: "b c", DuplicateIDs: []articlesim.ArticleID{2}, IsUnique: false, DuplicateGroupID: 2,
+				},
+				AddedDuplicateIDs:   []articlesim.ArticleID{2},
+				RemovedDuplicateIDs: []articlesim.ArticleID{1},
+			},
+			expectedArticle: []articlesim.Article{
+				{ID: 1, Content: "a", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 2, Content: "b", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 2},
+			},
+			expectedGroups: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 2, ArticleIDs: []articlesim.ArticleID{2, 3}},
+			},
+		},
+		"when oldest article becomes unique": {
+			contents: []string{"a", "a b", "c"},
+			id:       1,
+			content:  "c d",
+			expectedUpdate: articlesim.ArticleUpdate{
+				Article: articlesim.Article{
+					ID: 1, Content: "c d", DuplicateIDs: []articlesim.ArticleID{3}, IsUnique: false, DuplicateGroupID: 2,
+				},
+				AddedDuplicateIDs:   []articlesim.ArticleID{3},
+				RemovedDuplicateIDs: []articlesim.ArticleID{2},
+			},
+			expectedArticle: []articlesim.Article{
+				{ID: 2, Content: "a b", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+				{ID: 3, Content: "c", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 2},
+			},
+			expectedGroups: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 2, ArticleIDs: []articlesim.ArticleID{1, 3}},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := newService(t, tc.contents...)
+
+			update, err := s.UpdateArticle(context.Background(), tc.id, tc.content)
+
+			require.NoError(t, err)
+			articles := append(tc.expectedArticle, tc.expectedUpdate.Article)
+			expectedUpdate := tc.expectedUpdate
+			expectedUpdate.Article = stored(expectedUpdate.Article, articles)
+			assert.Equal(t, expectedUpdate, update)
+
+			for _, expected := range articles {
+				art, err := s.ArticleByID(context.Background(), expected.ID)
+
+				require.NoError(t, err)
+				assert.Equal(t, stored(expected, articles), art)
+			}
+
+			groups, _, err := s.DuplicateGroups(context.Background(), 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedGroups, groups)
+		})
+	}
+}
+
+func TestService_UpdateArticle_KeepsMetadata(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+	metadata := articlesim.Metadata{
+		Title:       "Hello",
+		SourceURL:   "https://example.com/hello",
+		Author:      "John",
+		PublishedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tags:        []string{"news"},
+	}
+
+	art, err := s.CreateArticle(ctx, "a", metadata)
+	require.NoError(t, err)
+	assert.Equal(t, metadata, art.Metadata)
+
+	update, err := s.UpdateArticle(ctx, art.ID, "b")
+
+	require.NoError(t, err)
+	assert.Equal(t, metadata, update.Article.Metadata)
+
+	art, err = s.ArticleByID(ctx, art.ID)
+	require.NoError(t, err)
+	assert.Equal(t, metadata, art.Metadata)
+}
+
+func TestService_UpdateArticle_NotFound(t *testing.T) {
+	s := newService(t, "a")
+
+	_, err := s.UpdateArticle(context.Background(), 2, "b")
+
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+}
+
+func TestService_DeleteArticle_KeepsLinksOfNewUniqueArticle(t *testing.T) {
+	s := newService(t, "a", "b", "b c")
+
+	// the updated article links to the unique article which has no back link
+	_, err := s.UpdateArticle(context.Background(), 1, "b d")
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteArticle(context.Background(), 3))
+
+	articles := []articlesim.Article{
+		{ID: 1, Content: "b d", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 2},
+		{ID: 2, Content: "b", DuplicateIDs: []articlesim.ArticleID{1}, IsUnique: false, DuplicateGroupID: 2},
+	}
+
+	for _, expected := range articles {
+		art, err := s.ArticleByID(context.Background(), expected.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, stored(expected, articles), art)
+	}
+
+	groups, _, err := s.DuplicateGroups(context.Background(), 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.DuplicateGroupResp{
+		{DuplicateGroupID: 2, ArticleIDs: []articlesim.ArticleID{1, 2}},
+	}, groups)
+}
+
+func TestService_DeleteArticle_NotFound(t *testing.T) {
+	s := newService(t, "a")
+
+	err := s.DeleteArticle(context.Background(), 2)
+
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+}
+
+func TestService_Compare(t *testing.T) {
+	s := newService(t, "a b", "b c")
+
+	res, err := s.Compare(context.Background(), 1, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(1), res.ArticleID)
+	assert.Equal(t, articlesim.ArticleID(2), res.OtherArticleID)
+	assert.Equal(t, []string{"a", "b"}, res.Words)
+	assert.Equal(t, []string{"b", "c"}, res.OtherWords)
+	assert.True(t, res.IsSimilar)
+}
+
+func TestService_Compare_NotFound(t *testing.T) {
+	s := newService(t, "a")
+
+	_, err := s.Compare(context.Background(), 1, 2)
+
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+}
+
+func TestService_Search(t *testing.T) {
+	type match struct {
+		id          articlesim.ArticleID
+		isDuplicate bool
+	}
+
+	for name, tc := range map[string]struct {
+		content  string
+		limit    int
+		expected []match
+	}{
+		"when no similar articles": {
+			content:  "x",
+			limit:    10,
+			expected: []match{{id: 1, isDuplicate: false}, {id: 2, isDuplicate: false}, {id: 3, isDuplicate: false}},
+		},
+		"when matches ordered by score": {
+			content:  "a b c",
+			limit:    10,
+			expected: []match{{id: 3, isDuplicate: true}, {id: 2, isDuplicate: true}, {id: 1, isDuplicate: false}},
+		},
+		"when matches are limited": {
+			content:  "a b c",
+			limit:    2,
+			expected: []match{{id: 3, isDuplicate: true}, {id: 2, isDuplicate: true}},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := newService(t, "a d e f g h i j k", "a b d", "a b c")
+
+			matches, err := s.Search(context.Background(), tc.content, tc.limit)
+
+			require.NoError(t, err)
+
+			res := make([]match, 0, len(matches))
+			for _, m := range matches {
+				res = append(res, match{id: m.Article.ID, isDuplicate: m.IsDuplicate})
+			}
+
+			assert.Equal(t, tc.expected, res)
+
+			_, err = s.ArticleByID(context.Background(), 4)
+			assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+		})
+	}
+}
+
+func TestService_MigrateTokens(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	s := New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	// articles stored before tokens were cached have neither tokens nor index keys
+	for _, art := range []articlesim.Article{
+		{ID: 1, Content: "a b", Tokens: articlesim.Tokens{}, DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+		{ID: 2, Content: "b c", Tokens: articlesim.Tokens{}, DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 2},
+	} {
+		require.NoError(t, st.CreateArticle(ctx, art.ID, art.Content, art.Metadata, art.Tokens, art.Duplicates,
+			art.IsUnique, art.DuplicateGroupID))
+	}
+
+	comparison, err := s.Compare(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, comparison.Words)
+
+	migrated, err := s.MigrateTokens(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, migrated)
+
+	art, err := s.ArticleByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, wordSimilarity{}.Tokenize("b c"), art.Tokens)
+
+	matches, err := s.Search(ctx, "c", 10)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.True(t, matches[0].IsDuplicate)
+	assert.Equal(t, articlesim.ArticleID(2), matches[0].Article.ID)
+
+	params, err := st.IndexParams(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "single", params)
+}
+
+func TestService_EnsureIndex(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	s := New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	// articles stored before the index was introduced have no index keys
+	require.NoError(t, st.CreateArticle(ctx, 1, "a b", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+
+	require.NoError(t, s.EnsureIndex(ctx))
+
+	art, err := s.CreateArticle(ctx, "a b", articlesim.Metadata{})
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{1}, art.DuplicateIDs)
+
+	require.NoError(t, s.EnsureIndex(ctx))
+
+	err = New(wordSimilarity{}, otherKeyIndex{}, st).EnsureIndex(ctx)
+	assert.True(t, errors.Is(err, ErrIndexParamsChanged))
+}
+
+func TestService_UniqueArticles(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents     []string
+		cursor       articlesim.ArticleID
+		limit        int
+		expected     []articlesim.ArticleID
+		expectedNext articlesim.ArticleID
+	}{
+		"when no articles": {
+			contents:     nil,
+			limit:        10,
+			expected:     []articlesim.ArticleID{},
+			expectedNext: 0,
+		},
+		"when unique contents": {
+			contents:     []string{"a", "b"},
+			limit:        10,
+			expected:     []articlesim.ArticleID{1, 2},
+			expectedNext: 0,
+		},
+		"when duplicate content": {
+			contents:     []string{"a", "b", "a c"},
+			limit:        10,
+			expected:     []articlesim.ArticleID{1, 2},
+			expectedNext: 0,
+		},
+		"when content bridges duplicate groups": {
+			contents:     []string{"a", "b", "a b"},
+			limit:        10,
+			expected:     []articlesim.ArticleID{1},
+			expectedNext: 0,
+		},
+		"when first page": {
+			contents:     []string{"a", "b", "c", "a d", "e"},
+			limit:        2,
+			expected:     []articlesim.ArticleID{1, 2},
+			expectedNext: 2,
+		},
+		"when next page skips duplicates": {
+			contents:     []string{"a", "b", "c", "a d", "e"},
+			cursor:       2,
+			limit:        2,
+			expected:     []articlesim.ArticleID{3, 5},
+			expectedNext: 0,
+		},
+		"when page is exactly full": {
+			contents:     []string{"a", "b"},
+			limit:        2,
+			expected:     []articlesim.ArticleID{1, 2},
+			expectedNext: 0,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := newService(t, tc.contents...)
+
+			articles, next, err := s.UniqueArticles(context.Background(), articlesim.ArticleFilter{}, tc.cursor, tc.limit)
+
+			require.NoError(t, err)
+
+			ids := make([]articlesim.ArticleID, 0, len(articles))
+			for _, art := range articles {
+				ids = append(ids, art.ID)
+			}
+
+			assert.Equal(t, tc.expected, ids)
+			assert.Equal(t, tc.expectedNext, next)
+		})
+	}
+}
+
+func TestService_UniqueArticles_Filter(t *testing.T) {
+	ctx := context.Background()
+	s := New(wordSimilarity{}, singleKeyIndex{}, memory.New())
+
+	for _, input := range []articlesim.ArticleInput{
+		{Content: "a", Metadata: articlesim.Metadata{
+			Title: "A", SourceURL: "https://www.example.com/a", Author: "", Tags: []string{"politics"},
+			PublishedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{Content: "b", Metadata: articlesim.Metadata{
+			Title: "B", SourceURL: "https://news.org/b", Author: "", Tags: []string{"sport", "politics"},
+			PublishedAt: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{Content: "c", Metadata: articlesim.Metadata{}},
+		{Content: "a d", Metadata: articlesim.Metadata{
+			Title: "D", SourceURL: "https://news.org/d", Author: "", Tags: []string{"politics"},
+			PublishedAt: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	} {
+		_, err := s.CreateArticle(ctx, input.Content, input.Metadata)
+		require.NoError(t, err)
+	}
+
+	for name, tc := range map[string]struct {
+		filter   articlesim.ArticleFilter
+		expected []articlesim.ArticleID
+	}{
+		"when no filter": {
+			filter:   articlesim.ArticleFilter{},
+			expected: []articlesim.ArticleID{1, 2, 3},
+		},
+		"when source": {
+			filter:   articlesim.ArticleFilter{Source: "example.com"},
+			expected: []articlesim.ArticleID{1},
+		},
+		"when source of duplicate": {
+			filter:   articlesim.ArticleFilter{Source: "news.org"},
+			expected: []articlesim.ArticleID{2},
+		},
+		"when tag": {
+			filter:   articlesim.ArticleFilter{Tag: "politics"},
+			expected: []articlesim.ArticleID{1, 2},
+		},
+		"when published from": {
+			filter:   articlesim.ArticleFilter{PublishedFrom: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)},
+			expected: []articlesim.ArticleID{2},
+		},
+		"when published to": {
+			filter:   articlesim.ArticleFilter{PublishedTo: time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC)},
+			expected: []articlesim.ArticleID{1},
+		},
+		"when nothing matches": {
+			filter:   articlesim.ArticleFilter{Source: "example.com", Tag: "sport"},
+			expected: []articlesim.ArticleID{},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			articles, next, err := s.UniqueArticles(ctx, tc.filter, 0, 10)
+
+			require.NoError(t, err)
+
+			ids := make([]articlesim.ArticleID, 0, len(articles))
+			for _, art := range articles {
+				ids = append(ids, art.ID)
+			}
+
+			assert.Equal(t, tc.expected, ids)
+			assert.Equal(t, articlesim.ArticleID(0), next)
+		})
+	}
+}
+
+func TestService_DuplicateGroups(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents     []string
+		cursor       articlesim.DuplicateGroupID
+		limit        int
+		expected     []articlesim.DuplicateGroupResp
+		expectedNext articlesim.DuplicateGroupID
+	}{
+		"when unique contents": {
+			contents:     []string{"a", "b"},
+			limit:        10,
+			expected:     []articlesim.DuplicateGroupResp{},
+			expectedNext: 0,
+		},
+		"when several duplicate groups": {
+			contents: []string{"a", "b", "a c", "b d"},
+			limit:    10,
+			expected: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 1, ArticleIDs: []articlesim.ArticleID{1, 3}},
+				{DuplicateGroupID: 2, ArticleIDs: []articlesim.ArticleID{2, 4}},
+			},
+			expectedNext: 0,
+		},
+		"when content bridges duplicate groups": {
+			contents: []string{"a", "b", "a b"},
+			limit:    10,
+			expected: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 1, ArticleIDs: []articlesim.ArticleID{1, 2, 3}},
+			},
+			expectedNext: 0,
+		},
+		"when first page": {
+			contents: []string{"a", "b", "c", "a d", "b e", "c f"},
+			limit:    2,
+			expected: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 1, ArticleIDs: []articlesim.ArticleID{1, 4}},
+				{DuplicateGroupID: 2, ArticleIDs: []articlesim.ArticleID{2, 5}},
+			},
+			expectedNext: 2,
+		},
+		"when next page": {
+			contents: []string{"a", "b", "c", "a d", "b e", "c f"},
+			cursor:   2,
+			limit:    2,
+			expected: []articlesim.DuplicateGroupResp{
+				{DuplicateGroupID: 3, ArticleIDs: []articlesim.ArticleID{3, 6}},
+			},
+			expectedNext: 0,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := newService(t, tc.contents...)
+
+			groups, next, err := s.DuplicateGroups(context.Background(), tc.cursor, tc.limit)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, groups)
+			assert.Equal(t, tc.expectedNext, next)
+		})
+	}
+}
+
+func TestService_ExportArticles(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t, "a", "b", "a c")
+
+	var exported []articlesim.Article
+
+	err := s.ExportArticles(ctx, func(art articlesim.Article) error {
+		if art.ID == 1 {
+			// changes made during the export are not exported
+			require.NoError(t, s.DeleteArticle(ctx, 2))
+		}
+
+		exported = append(exported, art)
+
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, exported, 3)
+
+	for i, art := range exported {
+		assert.Equal(t, articlesim.ArticleID(i+1), art.ID)
+	}
+
+	assert.Equal(t, "b", exported[1].Content)
+	assert.True(t, exported[0].IsUnique)
+	assert.False(t, exported[2].IsUnique)
+	assert.Equal(t, articlesim.DuplicateGroupID(1), exported[2].DuplicateGroupID)
+	assert.Equal(t, []articlesim.ArticleID{1}, exported[2].DuplicateIDs)
+	require.Len(t, exported[2].Duplicates, 1)
+	assert.Equal(t, "words", exported[2].Duplicates[0].Algorithm)
+}
+
+func TestService_ExportArticles_Fails(t *testing.T) {
+	errFailed := errors.New("failed")
+	s := newService(t, "a", "b")
+	visited := 0
+
+	err := s.ExportArticles(context.Background(), func(art articlesim.Article) error {
+		visited++
+
+		return errFailed
+	})
+
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 1, visited)
+}
@@ -0,0 +1,181 @@
+package article
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+)
+
+// ImportArticles stores new articles in a single transaction and returns them in the same order. Every article is
+// checked for duplicates like by CreateArticle, among stored articles and among the previous ones, but the articles
+// are written to the storage in bulk when all of them are checked.
+func (a *Service) ImportArticles(ctx context.Context, inputs []articlesim.ArticleInput) ([]articlesim.Article, error) {
+	var articles []articlesim.Article
+
+	err := a.storage.WithTransaction(ctx, func(ctx context.Context) error {
+		b := newBatch(a.storage)
+
+		for _, input := range inputs {
+			id, err := a.storage.NextArticleID(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get next article id: %w", err)
+			}
+
+			if _, err := a.createArticle(ctx, b, id, input.Content, input.Metadata); err != nil {
+				return err
+			}
+		}
+
+		if err := a.storage.CreateArticles(ctx, b.articles); err != nil {
+			return fmt.Errorf("failed to create articles: %w", err)
+		}
+
+		articles = make([]articlesim.Article, 0, len(b.articles))
+		for _, art := range b.articles {
+			articles = append(articles, art.Article)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// batch buffers articles created by the import until they are written at once. Reads see the buffered articles
+// along with the stored ones, so articles of the batch are duplicates of each other like stored articles.
+// Changes of stored articles are passed to the storage.
+type batch struct {
+	Storage
+
+	articles []articlesim.IndexedArticle
+	// positions are indexes of the buffered articles by id.
+	positions map[articlesim.ArticleID]int
+	// keys are indexes of the buffered articles by index key.
+	keys map[uint64][]int
+}
+
+func newBatch(storage Storage) *batch {
+	return &batch{
+		Storage:   storage,
+		articles:  make([]articlesim.IndexedArticle, 0),
+		positions: make(map[articlesim.ArticleID]int),
+		keys:      make(map[uint64][]int),
+	}
+}
+
+func (b *batch) CreateArticle(ctx context.Context, id articlesim.ArticleID, content string,
+	metadata articlesim.Metadata, tokens articlesim.Tokens, duplicates []articlesim.Duplicate, isUnique bool,
+	duplicateGroupID articlesim.DuplicateGroupID) error {
+	b.positions[id] = len(b.articles)
+	b.articles = append(b.articles, articlesim.IndexedArticle{
+		Article: articlesim.Article{
+			ID:               id,
+			Content:          content,
+			Metadata:         metadata,
+			Tokens:           tokens,
+			DuplicateIDs:     articlesim.DuplicateIDsOf(duplicates),
+			Duplicates:       duplicates,
+			IsUnique:         isUnique,
+			DuplicateGroupID: duplicateGroupID,
+		},
+		Keys: nil,
+	})
+
+	return nil
+}
+
+func (b *batch) UpdateArticle(ctx context.Context, id articlesim.ArticleID,
+	duplicates []articlesim.Duplicate) error {
+	i, ok := b.positions[id]
+	if !ok {
+		return b.Storage.UpdateArticle(ctx, id, duplicates)
+	}
+
+	b.articles[i].DuplicateIDs = articlesim.DuplicateIDsOf(duplicates)
+	b.articles[i].Duplicates = duplicates
+
+	return nil
+}
+
+func (b *batch) ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error) {
+	i, ok := b.positions[id]
+	if !ok {
+		return b.Storage.ArticleByID(ctx, id)
+	}
+
+	return b.articles[i].Article, nil
+}
+
+// CreateDuplicateGroup adds only stored articles to the group. Buffered articles are added to the group they belong
+// to when they are written.
+func (b *batch) CreateDuplicateGroup(ctx context.Context, duplicateGroupID articlesim.DuplicateGroupID,
+	articleID articlesim.ArticleID) error {
+	if _, ok := b.positions[articleID]; ok {
+		return nil
+	}
+
+	return b.Storage.CreateDuplicateGroup(ctx, duplicateGroupID, articleID)
+}
+
+func (b *batch) MergeDuplicateGroups(ctx context.Context, duplicateGroupID articlesim.DuplicateGroupID,
+	mergedGroupIDs []articlesim.DuplicateGroupID) error {
+	for i := range b.articles {
+		art := &b.articles[i]
+
+		for _, gid := range mergedGroupIDs {
+			if art.DuplicateGroupID == gid {
+				art.DuplicateGroupID = duplicateGroupID
+				art.IsUnique = false
+			}
+		}
+	}
+
+	return b.Storage.MergeDuplicateGroups(ctx, duplicateGroupID, mergedGroupIDs)
+}
+
+func (b *batch) IndexArticle(ctx context.Context, id articlesim.ArticleID, keys []uint64) error {
+	i, ok := b.positions[id]
+	if !ok {
+		return b.Storage.IndexArticle(ctx, id, keys)
+	}
+
+	b.articles[i].Keys = keys
+
+	for _, k := range keys {
+		b.keys[k] = append(b.keys[k], i)
+	}
+
+	return nil
+}
+
+// CandidateArticles returns stored and buffered articles sharing at least one of the keys ordered by id.
+func (b *batch) CandidateArticles(ctx context.Context, keys []uint64) ([]articlesim.Article, error) {
+	articles, err := b.Storage.CandidateArticles(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]struct{})
+
+	for _, k := range keys {
+		for _, i := range b.keys[k] {
+			if _, ok := seen[i]; ok {
+				continue
+			}
+
+			seen[i] = struct{}{}
+			articles = append(articles, b.articles[i].Article)
+		}
+	}
+
+	sort.Slice(articles, func(i, j int) bool {
+		return articles[i].ID < articles[j].ID
+	})
+
+	return articles, nil
+}
@@ -0,0 +1,140 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/file"
+	"github.com/devchallenge/article-similarity/internal/memory"
+)
+
+func TestService_ImportArticles(t *testing.T) {
+	for name, tc := range map[string]struct {
+		stored   []string
+		imported []string
+	}{
+		"when unique contents": {
+			stored:   nil,
+			imported: []string{"a", "b"},
+		},
+		"when duplicates within batch": {
+			stored:   nil,
+			imported: []string{"a", "b", "a c", "b d"},
+		},
+		"when duplicates of stored articles": {
+			stored:   []string{"a", "b"},
+			imported: []string{"a c", "e", "b d"},
+		},
+		"when content bridges stored and imported groups": {
+			stored:   []string{"a", "b"},
+			imported: []string{"c", "a b c", "d"},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			created := newService(t, append(tc.stored, tc.imported...)...)
+			imported := newService(t, tc.stored...)
+
+			articles, err := imported.ImportArticles(ctx, inputsOf(tc.imported...))
+
+			require.NoError(t, err)
+			require.Len(t, articles, len(tc.imported))
+
+			// imported articles are stored like articles created one by one
+			for i := range append(tc.stored, tc.imported...) {
+				id := articlesim.ArticleID(i + 1)
+
+				expected, err := created.ArticleByID(ctx, id)
+				require.NoError(t, err)
+				art, err := imported.ArticleByID(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, expected, art)
+			}
+
+			for i, art := range articles {
+				assert.Equal(t, articlesim.ArticleID(len(tc.stored)+i+1), art.ID)
+				assert.Equal(t, tc.imported[i], art.Content)
+			}
+
+			expectedGroups, _, err := created.DuplicateGroups(ctx, 0, 10)
+			require.NoError(t, err)
+			groups, _, err := imported.DuplicateGroups(ctx, 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, expectedGroups, groups)
+
+			matches, err := imported.Search(ctx, tc.imported[0], 10)
+			require.NoError(t, err)
+			assert.NotEmpty(t, matches)
+		})
+	}
+}
+
+func TestService_ImportArticles_FileStorage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := file.Open(dir)
+	require.NoError(t, err)
+
+	s := New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	_, err = s.CreateArticle(ctx, "a", articlesim.Metadata{})
+	require.NoError(t, err)
+	_, err = s.ImportArticles(ctx, inputsOf("b", "a c"))
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+
+	st, err = file.Open(dir)
+	require.NoError(t, err)
+
+	s = New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	art, err := s.ArticleByID(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{1}, art.DuplicateIDs)
+
+	groups, _, err := s.DuplicateGroups(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.DuplicateGroupResp{
+		{DuplicateGroupID: 1, ArticleIDs: []articlesim.ArticleID{1, 3}},
+	}, groups)
+	require.NoError(t, st.Close())
+}
+
+func TestService_ImportArticles_Fails(t *testing.T) {
+	errFailed := errors.New("failed")
+	st := failingStorage{Storage: memory.New(), err: errFailed}
+	s := New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	_, err := s.ImportArticles(context.Background(), inputsOf("a", "b"))
+
+	assert.True(t, errors.Is(err, errFailed))
+
+	_, err = s.ArticleByID(context.Background(), 1)
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+}
+
+func inputsOf(contents ...string) []articlesim.ArticleInput {
+	inputs := make([]articlesim.ArticleInput, 0, len(contents))
+	for _, content := range contents {
+		inputs = append(inputs, articlesim.ArticleInput{Content: content, Metadata: articlesim.Metadata{}})
+	}
+
+	return inputs
+}
+
+// failingStorage fails to store articles in bulk.
+type failingStorage struct {
+	Storage
+	err error
+}
+
+func (s failingStorage) CreateArticles(ctx context.Context, articles []articlesim.IndexedArticle) error {
+	return s.err
+}
@@ -0,0 +1,311 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+)
+
+// Stager keeps the staging storage where stored articles are clustered again.
+type Stager interface {
+	// Staging returns the staging storage. It keeps articles reclustered by the interrupted run.
+	Staging(ctx context.Context) (Storage, error)
+	// SwapStaging blocks changes of stored articles, runs catchUp and replaces stored articles, duplicate groups
+	// and index keys with the staging ones at once, then empties the staging. catchUp reads stored articles with
+	// the context it is given, so the reads do not wait for the blocked changes. When catchUp fails, the staging is
+	// kept and changes are unblocked.
+	SwapStaging(ctx context.Context, catchUp func(ctx context.Context) error) error
+}
+
+// Reclusterer recomputes duplicates, unique articles and duplicate groups of stored articles with the current
+// similarity configuration. Articles are added to the staging in id order like new ones, so the result is the same
+// as if they were created with the current configuration. The staging replaces stored articles when all articles
+// are reclustered.
+//
+// Articles already in the staging with the same content are kept, so the interrupted reclustering is resumed.
+// The change sequence is saved before articles are staged. Before the swap, changes of stored articles are blocked
+// while only articles created, updated or deleted after the saved sequence are staged again, so changes made while
+// reclustering runs survive the swap and changes are blocked for the time of restaging them.
+type Reclusterer struct {
+	service *Service
+	stager  Stager
+
+	mu     sync.Mutex
+	status articlesim.ReclusterStatus
+}
+
+func NewReclusterer(service *Service, stager Stager) *Reclusterer {
+	return &Reclusterer{
+		service: service,
+		stager:  stager,
+		mu:      sync.Mutex{},
+		status: articlesim.ReclusterStatus{
+			State:         articlesim.ReclusterIdle,
+			Processed:     0,
+			Reclustered:   0,
+			Deleted:       0,
+			LastArticleID: 0,
+			Error:         "",
+		},
+	}
+}
+
+// Run reclusters articles and returns the final status.
+func (r *Reclusterer) Run(ctx context.Context) (articlesim.ReclusterStatus, error) {
+	if _, err := r.begin(); err != nil {
+		return articlesim.ReclusterStatus{}, err
+	}
+
+	err := r.run(ctx)
+
+	return r.Status(), err
+}
+
+// Start runs reclustering in the background and returns its status. Progress is returned by Status.
+func (r *Reclusterer) Start() (articlesim.ReclusterStatus, error) {
+	status, err := r.begin()
+	if err != nil {
+		return articlesim.ReclusterStatus{}, err
+	}
+
+	go func() {
+		if err := r.run(context.Background()); err != nil {
+			log.Printf("failed to recluster: %v", err)
+		}
+	}()
+
+	return status, nil
+}
+
+// Status returns the progress of the running or the last reclustering.
+func (r *Reclusterer) Status() articlesim.ReclusterStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.status
+}
+
+func (r *Reclusterer) begin() (articlesim.ReclusterStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.status.State == articlesim.ReclusterRunning {
+		return articlesim.ReclusterStatus{}, articlesim.ErrReclusterRunning
+	}
+
+	r.status = articlesim.ReclusterStatus{
+		State:         articlesim.ReclusterRunning,
+		Processed:     0,
+		Reclustered:   0,
+		Deleted:       0,
+		LastArticleID: 0,
+		Error:         "",
+	}
+
+	return r.status, nil
+}
+
+func (r *Reclusterer) run(ctx context.Context) error {
+	err := r.recluster(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.status.State = articlesim.ReclusterFailed
+		r.status.Error = err.Error()
+
+		return err
+	}
+
+	r.status.State = articlesim.ReclusterDone
+
+	return nil
+}
+
+func (r *Reclusterer) recluster(ctx context.Context) error {
+	st, err := r.stager.Staging(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open staging: %w", err)
+	}
+
+	staging := New(r.service.similar, r.service.index, st)
+
+	since, err := r.service.storage.ChangeSequence(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get change sequence: %w", err)
+	}
+
+	if err := r.stage(ctx, staging); err != nil {
+		return err
+	}
+
+	if err := st.SetIndexParams(ctx, r.service.index.Params()); err != nil {
+		return fmt.Errorf("failed to set staging index params: %w", err)
+	}
+
+	catchUp := func(ctx context.Context) error {
+		return r.catchUp(ctx, staging, since)
+	}
+
+	if err := r.stager.SwapStaging(ctx, catchUp); err != nil {
+		return fmt.Errorf("failed to swap staging: %w", err)
+	}
+
+	return nil
+}
+
+// stage restages stored articles and unstages deleted ones.
+func (r *Reclusterer) stage(ctx context.Context, staging *Service) error {
+	err := r.service.storage.ForEachArticle(ctx, func(art articlesim.Article) error {
+		return r.restage(ctx, staging, art)
+	})
+	if err != nil {
+		return err
+	}
+
+	deleted, err := staging.unstageDeleted(ctx, r.service.storage)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.status.Deleted += deleted
+	r.mu.Unlock()
+
+	return nil
+}
+
+// catchUp restages articles created or updated after the change sequence and unstages the deleted ones, so other
+// articles are neither read nor compared.
+func (r *Reclusterer) catchUp(ctx context.Context, staging *Service, since int64) error {
+	ids, err := r.service.storage.ChangedArticleIDs(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to get changed articles: %w", err)
+	}
+
+	for _, id := range ids {
+		art, err := r.service.storage.ArticleByID(ctx, id)
+
+		switch {
+		case errors.Is(err, articlesim.ErrArticleNotFound):
+			err = r.unstage(ctx, staging, id)
+		case err != nil:
+			return fmt.Errorf("failed to get changed article=%d: %w", id, err)
+		default:
+			err = r.restage(ctx, staging, art)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restage restages the stored article. Articles are counted as processed once, when they are staged after the last
+// processed article.
+func (r *Reclusterer) restage(ctx context.Context, staging *Service, art articlesim.Article) error {
+	reclustered, err := staging.restage(ctx, art)
+	if err != nil {
+		return fmt.Errorf("failed to recluster article=%d: %w", art.ID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reclustered {
+		r.status.Reclustered++
+	}
+
+	if art.ID <= r.status.LastArticleID {
+		return nil
+	}
+
+	r.status.Processed++
+	r.status.LastArticleID = art.ID
+
+	if r.status.Processed%progressArticles == 0 {
+		log.Printf("reclustered %d articles, last article=%d", r.status.Processed, art.ID)
+	}
+
+	return nil
+}
+
+// unstage deletes the staged article which is deleted from the stored ones.
+func (r *Reclusterer) unstage(ctx context.Context, staging *Service, id articlesim.ArticleID) error {
+	err := staging.DeleteArticle(ctx, id)
+	if errors.Is(err, articlesim.ErrArticleNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete staged article=%d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	r.status.Deleted++
+	r.mu.Unlock()
+
+	return nil
+}
+
+// restage adds the article to the staging like a new one. The article kept by the interrupted run is reclustered
+// only when its content is changed since. It returns whether the article is reclustered.
+func (a *Service) restage(ctx context.Context, art articlesim.Article) (bool, error) {
+	reclustered := false
+
+	err := a.storage.WithTransaction(ctx, func(ctx context.Context) error {
+		staged, err := a.storage.ArticleByID(ctx, art.ID)
+
+		switch {
+		case errors.Is(err, articlesim.ErrArticleNotFound):
+			_, err = a.createArticle(ctx, a.storage, art.ID, art.Content, art.Metadata)
+		case err != nil:
+			return fmt.Errorf("failed to get staged article: %w", err)
+		case staged.Content == art.Content:
+			return nil
+		default:
+			_, err = a.updateArticle(ctx, art.ID, art.Content)
+		}
+
+		reclustered = err == nil
+
+		return err
+	})
+
+	return reclustered, err
+}
+
+// unstageDeleted deletes staged articles which are not stored anymore, as they are deleted after the interrupted
+// run. It returns the number of deleted articles.
+func (a *Service) unstageDeleted(ctx context.Context, stored Storage) (int, error) {
+	deletedIDs := make([]articlesim.ArticleID, 0)
+
+	err := a.storage.ForEachArticle(ctx, func(art articlesim.Article) error {
+		_, err := stored.ArticleByID(ctx, art.ID)
+		if errors.Is(err, articlesim.ErrArticleNotFound) {
+			deletedIDs = append(deletedIDs, art.ID)
+
+			return nil
+		}
+
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find deleted articles: %w", err)
+	}
+
+	for _, id := range deletedIDs {
+		if err := a.DeleteArticle(ctx, id); err != nil {
+			return 0, fmt.Errorf("failed to delete staged article=%d: %w", id, err)
+		}
+	}
+
+	return len(deletedIDs), nil
+}
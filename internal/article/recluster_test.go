@@ -0,0 +1,257 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/memory"
+)
+
+// strictSimilarity is wordSimilarity with the custom threshold.
+type strictSimilarity struct {
+	wordSimilarity
+	threshold float64
+}
+
+func (s strictSimilarity) Threshold(languageA, languageB string) float64 {
+	return s.threshold
+}
+
+func (s strictSimilarity) IsDuplicate(tokensA, tokensB articlesim.Tokens) (float64, bool) {
+	score := s.Compare(tokensA, tokensB)
+
+	return score, score >= s.threshold
+}
+
+// memoryStager stages articles in the memory storage. It fails to swap the staging while swapErr is set, waits
+// for the release channel before the staging is opened and calls beforeSwap when articles are staged.
+type memoryStager struct {
+	st         *memory.Storage
+	swapErr    error
+	release    chan struct{}
+	beforeSwap func()
+}
+
+func (s *memoryStager) Staging(ctx context.Context) (Storage, error) {
+	if s.release != nil {
+		<-s.release
+	}
+
+	return s.st.Staging(), nil
+}
+
+func (s *memoryStager) SwapStaging(ctx context.Context, catchUp func(ctx context.Context) error) error {
+	if s.beforeSwap != nil {
+		s.beforeSwap()
+	}
+
+	if s.swapErr != nil {
+		return s.swapErr
+	}
+
+	return s.st.SwapStaging(ctx, catchUp)
+}
+
+// readCountingStorage counts reads of all articles and articles by id.
+type readCountingStorage struct {
+	Storage
+	forEach int
+	byID    int
+}
+
+func (s *readCountingStorage) ForEachArticle(ctx context.Context, fn func(art articlesim.Article) error) error {
+	s.forEach++
+
+	return s.Storage.ForEachArticle(ctx, fn)
+}
+
+func (s *readCountingStorage) ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error) {
+	s.byID++
+
+	return s.Storage.ArticleByID(ctx, id)
+}
+
+func TestReclusterer_Run(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	s := New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	for _, content := range []string{"a b", "a c", "d"} {
+		_, err := s.CreateArticle(ctx, content, articlesim.Metadata{})
+		require.NoError(t, err)
+	}
+
+	strict := New(strictSimilarity{wordSimilarity: wordSimilarity{}, threshold: 0.5}, singleKeyIndex{}, st)
+
+	status, err := NewReclusterer(strict, &memoryStager{st: st, swapErr: nil, release: nil, beforeSwap: nil}).Run(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ReclusterStatus{
+		State:         articlesim.ReclusterDone,
+		Processed:     3,
+		Reclustered:   3,
+		Deleted:       0,
+		LastArticleID: 3,
+		Error:         "",
+	}, status)
+
+	art, err := strict.ArticleByID(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, art.IsUnique)
+	assert.Empty(t, art.DuplicateIDs)
+
+	groups, _, err := strict.DuplicateGroups(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	articles, _, err := strict.UniqueArticles(ctx, articlesim.ArticleFilter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, articles, 3)
+
+	art, err = strict.CreateArticle(ctx, "e", articlesim.Metadata{})
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(4), art.ID)
+
+	params, err := st.IndexParams(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "single", params)
+}
+
+func TestReclusterer_Run_Resumes(t *testing.T) {
+	ctx := context.Background()
+	errSwap := errors.New("swap failed")
+	st := memory.New()
+	s := New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	for _, content := range []string{"a b", "a c", "d"} {
+		_, err := s.CreateArticle(ctx, content, articlesim.Metadata{})
+		require.NoError(t, err)
+	}
+
+	stager := &memoryStager{st: st, swapErr: errSwap, release: nil, beforeSwap: nil}
+	r := NewReclusterer(s, stager)
+
+	status, err := r.Run(ctx)
+	assert.True(t, errors.Is(err, errSwap))
+	assert.Equal(t, articlesim.ReclusterFailed, status.State)
+	assert.Equal(t, 3, status.Reclustered)
+
+	require.NoError(t, s.DeleteArticle(ctx, 3))
+	_, err = s.UpdateArticle(ctx, 2, "e")
+	require.NoError(t, err)
+
+	stager.swapErr = nil
+
+	status, err = r.Run(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ReclusterStatus{
+		State:         articlesim.ReclusterDone,
+		Processed:     2,
+		Reclustered:   1,
+		Deleted:       1,
+		LastArticleID: 2,
+		Error:         "",
+	}, status)
+
+	_, err = s.ArticleByID(ctx, 3)
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+
+	art, err := s.ArticleByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "e", art.Content)
+	assert.True(t, art.IsUnique)
+}
+
+func TestReclusterer_Run_KeepsChangesDuringRun(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	s := New(wordSimilarity{}, singleKeyIndex{}, st)
+
+	for _, content := range []string{"a b", "a c", "d"} {
+		_, err := s.CreateArticle(ctx, content, articlesim.Metadata{})
+		require.NoError(t, err)
+	}
+
+	reads := &readCountingStorage{Storage: st, forEach: 0, byID: 0}
+	strict := New(strictSimilarity{wordSimilarity: wordSimilarity{}, threshold: 0.5}, singleKeyIndex{}, reads)
+	stager := &memoryStager{st: st, swapErr: nil, release: nil, beforeSwap: nil}
+
+	// the articles are changed after they are staged and before the staging is swapped
+	stager.beforeSwap = func() {
+		_, err := s.CreateArticle(ctx, "d e", articlesim.Metadata{Title: "created"})
+		require.NoError(t, err)
+		_, err = s.UpdateArticle(ctx, 1, "a c")
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteArticle(ctx, 3))
+
+		reads.forEach, reads.byID = 0, 0
+	}
+
+	status, err := NewReclusterer(strict, stager).Run(ctx)
+
+	require.NoError(t, err)
+	// only the changed articles are read by the catch-up
+	assert.Equal(t, 0, reads.forEach)
+	assert.Equal(t, 3, reads.byID)
+	assert.Equal(t, articlesim.ReclusterStatus{
+		State:         articlesim.ReclusterDone,
+		Processed:     4,
+		Reclustered:   5,
+		Deleted:       1,
+		LastArticleID: 4,
+		Error:         "",
+	}, status)
+
+	art, err := strict.ArticleByID(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "d e", art.Content)
+	assert.Equal(t, "created", art.Metadata.Title)
+	assert.True(t, art.IsUnique)
+
+	art, err = strict.ArticleByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "a c", art.Content)
+	assert.Equal(t, []articlesim.ArticleID{2}, art.DuplicateIDs)
+
+	assert.False(t, art.IsUnique)
+
+	art, err = strict.ArticleByID(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, art.IsUnique)
+
+	_, err = strict.ArticleByID(ctx, 3)
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+
+	art, err = strict.CreateArticle(ctx, "f", articlesim.Metadata{})
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(5), art.ID)
+}
+
+func TestReclusterer_Start_AlreadyRunning(t *testing.T) {
+	s := newService(t, "a", "a b")
+	stager := &memoryStager{st: s.storage.(*memory.Storage), swapErr: nil, release: make(chan struct{}), beforeSwap: nil}
+	r := NewReclusterer(s, stager)
+
+	assert.Equal(t, articlesim.ReclusterIdle, r.Status().State)
+
+	status, err := r.Start()
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ReclusterRunning, status.State)
+
+	_, err = r.Start()
+	assert.True(t, errors.Is(err, articlesim.ErrReclusterRunning))
+
+	close(stager.release)
+
+	assert.Eventually(t, func() bool {
+		return r.Status().State == articlesim.ReclusterDone
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 2, r.Status().Processed)
+}
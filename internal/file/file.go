@@ -0,0 +1,1044 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/memory"
+)
+
+const (
+	snapshotFileName = "snapshot.json"
+	logFileName      = "log.jsonl"
+	lockFileName     = "lock"
+	stagingDirName   = "staging"
+
+	// compactRecords is the number of log records after which the log is compacted into the snapshot.
+	compactRecords = 10000
+
+	dirPerm  = 0o755
+	filePerm = 0o644
+
+	counterArticles        = "articles"
+	counterDuplicateGroups = "duplicate_groups"
+)
+
+type operation string
+
+const (
+	opAutoincrement        operation = "autoincrement"
+	opCreateArticle        operation = "create_article"
+	opUpdateArticle        operation = "update_article"
+	opCreateDuplicateGroup operation = "create_duplicate_group"
+	opMergeDuplicateGroups operation = "merge_duplicate_groups"
+	opIndexArticle         operation = "index_article"
+	opReindexArticle       operation = "reindex_article"
+	opRegroupArticle       operation = "regroup_article"
+	opDeleteArticle        operation = "delete_article"
+	opSetIndexParams       operation = "set_index_params"
+	opTransaction          operation = "transaction"
+)
+
+var (
+	ErrUnknownOperation = errors.New("unknown log operation")
+	ErrUnknownCounter   = errors.New("unknown autoincrement counter")
+	ErrNoStaging        = errors.New("no staging storage")
+	ErrLocked           = errors.New("data dir is used by another process")
+)
+
+// txKey marks the context of the running transaction with its storage.
+type txKey struct{}
+
+// record is a line of the append-only log. Data is the operation payload, for the transaction it is the list of
+// records without sequences.
+type record struct {
+	Sequence  int64           `json:"seq"`
+	Operation operation       `json:"op"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// snapshot is the state with the sequence of the last record applied to it.
+type snapshot struct {
+	Sequence int64           `json:"seq"`
+	State    *memory.Storage `json:"state"`
+}
+
+type autoincrementData struct {
+	Counter string `json:"counter"`
+}
+
+type createArticleData struct {
+	ID               articlesim.ArticleID        `json:"id"`
+	Content          string                      `json:"content"`
+	Title            string                      `json:"title"`
+	SourceURL        string                      `json:"source_url"`
+	Author           string                      `json:"author"`
+	PublishedAt      time.Time                   `json:"published_at"`
+	Tags             []string                    `json:"tags"`
+	Words            []string                    `json:"words"`
+	Language         string                      `json:"language"`
+	DuplicateIDs     []articlesim.ArticleID      `json:"duplicate_ids"`
+	Duplicates       []duplicateData             `json:"duplicates"`
+	IsUnique         bool                        `json:"is_unique"`
+	DuplicateGroupID articlesim.DuplicateGroupID `json:"duplicate_group_id"`
+}
+
+type updateArticleData struct {
+	ID           articlesim.ArticleID   `json:"id"`
+	DuplicateIDs []articlesim.ArticleID `json:"duplicate_ids"`
+	Duplicates   []duplicateData        `json:"duplicates"`
+}
+
+type regroupArticleData struct {
+	ID               articlesim.ArticleID        `json:"id"`
+	DuplicateIDs     []articlesim.ArticleID      `json:"duplicate_ids"`
+	Duplicates       []duplicateData             `json:"duplicates"`
+	IsUnique         bool                        `json:"is_unique"`
+	DuplicateGroupID articlesim.DuplicateGroupID `json:"duplicate_group_id"`
+}
+
+// duplicateData is a link to a duplicate article with the similarity score. Records written before scores were kept
+// have duplicate ids without duplicates.
+type duplicateData struct {
+	ID        articlesim.ArticleID `json:"id"`
+	Score     float64              `json:"score"`
+	Algorithm string               `json:"algorithm"`
+	Threshold float64              `json:"threshold"`
+}
+
+type deleteArticleData struct {
+	ID articlesim.ArticleID `json:"id"`
+}
+
+type createDuplicateGroupData struct {
+	ID        articlesim.DuplicateGroupID `json:"id"`
+	ArticleID articlesim.ArticleID        `json:"article_id"`
+}
+
+type mergeDuplicateGroupsData struct {
+	ID        articlesim.DuplicateGroupID   `json:"id"`
+	MergedIDs []articlesim.DuplicateGroupID `json:"merged_ids"`
+}
+
+type indexArticleData struct {
+	ID   articlesim.ArticleID `json:"id"`
+	Keys []uint64             `json:"keys"`
+}
+
+type indexParamsData struct {
+	Params string `json:"params"`
+}
+
+type reindexArticleData struct {
+	ID       articlesim.ArticleID `json:"id"`
+	Words    []string             `json:"words"`
+	Language string               `json:"language"`
+	Keys     []uint64             `json:"keys"`
+}
+
+// Storage is an embedded storage keeping all data in the memory storage. Every change is appended to the log file
+// before it is applied, so the data survives restarts. The log is periodically compacted into the snapshot file.
+type Storage struct {
+	// mu serializes changes and transactions, so records are applied in the order of the log. Reads outside
+	// transactions share it, so they do not return changes of the running transaction.
+	mu sync.RWMutex
+
+	dir string
+	// dirLock is the lock file held while the storage is open, so another process does not open the same directory.
+	dirLock  *os.File
+	state    *memory.Storage
+	sequence int64
+	records  int
+	log      *os.File
+	// tx buffers records of the running transaction until it is committed.
+	tx []record
+	// staging keeps articles reclustered beside the stored ones in the staging directory, it is nil until
+	// reclustering starts.
+	staging *Storage
+}
+
+// Open opens the storage in the directory. It restores the snapshot and replays the log written after it.
+// The directory is locked until the storage is closed, so it fails with ErrLocked while another process, e.g.
+// the server or a command, has the directory open.
+func Open(dir string) (*Storage, error) {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create data dir=%s: %w", dir, err)
+	}
+
+	lock, err := lockFile(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock data dir=%s: %w", dir, err)
+	}
+
+	s := &Storage{
+		mu:       sync.RWMutex{},
+		dir:      dir,
+		dirLock:  lock,
+		state:    memory.New(),
+		sequence: 0,
+		records:  0,
+		log:      nil,
+		tx:       nil,
+		staging:  nil,
+	}
+
+	if err := s.restore(); err != nil {
+		s.unlock()
+
+		return nil, fmt.Errorf("failed to restore: %w", err)
+	}
+
+	if err := s.compact(); err != nil {
+		s.unlock()
+
+		return nil, fmt.Errorf("failed to compact: %w", err)
+	}
+
+	return s, nil
+}
+
+// Close closes the log file and the staging storage.
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.staging != nil {
+		if err := s.staging.Close(); err != nil {
+			return fmt.Errorf("failed to close staging: %w", err)
+		}
+
+		s.staging = nil
+	}
+
+	if err := s.log.Close(); err != nil {
+		return fmt.Errorf("failed to close log: %w", err)
+	}
+
+	if err := s.dirLock.Close(); err != nil {
+		return fmt.Errorf("failed to unlock data dir: %w", err)
+	}
+
+	return nil
+}
+
+// unlock closes the log file and releases the lock of the storage which failed to open.
+func (s *Storage) unlock() {
+	if s.log != nil {
+		if err := s.log.Close(); err != nil {
+			log.Printf("failed to close log: %v", err)
+		}
+	}
+
+	if err := s.dirLock.Close(); err != nil {
+		log.Printf("failed to unlock data dir: %v", err)
+	}
+}
+
+// Staging opens the storage where articles are reclustered in the staging directory. It keeps reclustered articles
+// until they are swapped, so interrupted reclustering is resumed after restart.
+func (s *Storage) Staging() (*Storage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.staging != nil {
+		return s.staging, nil
+	}
+
+	staging, err := Open(s.path(stagingDirName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staging: %w", err)
+	}
+
+	s.staging = staging
+
+	return staging, nil
+}
+
+// SwapStaging blocks transactions and changes, runs catchUp and replaces the state with the staging one, then
+// removes the staging directory. catchUp reads the storage with the context of the blocking transaction. When it
+// fails, the state and the staging are kept. The state is written to the snapshot replaced atomically, so
+// the storage is restored either before or after the swap.
+func (s *Storage) SwapStaging(ctx context.Context, catchUp func(ctx context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.staging == nil {
+		return ErrNoStaging
+	}
+
+	if err := catchUp(context.WithValue(ctx, txKey{}, s)); err != nil {
+		return err
+	}
+
+	s.staging.mu.Lock()
+	s.state.Replace(s.staging.state)
+	s.staging.mu.Unlock()
+
+	if err := s.compact(); err != nil {
+		if rerr := s.restore(); rerr != nil {
+			log.Printf("failed to restore state after failed swap: %v", rerr)
+		}
+
+		return fmt.Errorf("failed to write swapped snapshot: %w", err)
+	}
+
+	if err := s.staging.Close(); err != nil {
+		log.Printf("failed to close staging: %v", err)
+	}
+
+	s.staging = nil
+
+	if err := os.RemoveAll(s.path(stagingDirName)); err != nil {
+		return fmt.Errorf("failed to remove staging: %w", err)
+	}
+
+	return nil
+}
+
+// WithTransaction runs fn exclusively and writes all its changes to the log as a single record, so they are
+// replayed together or not at all. The state is changed in its transaction, so when fn or the commit fails, the
+// changes are rolled back by the state.
+func (s *Storage) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.WithTransaction(ctx, func(ctx context.Context) error {
+		s.tx = make([]record, 0)
+
+		err := fn(context.WithValue(ctx, txKey{}, s))
+
+		records := s.tx
+		s.tx = nil
+
+		if err != nil {
+			return err
+		}
+
+		if len(records) == 0 {
+			return nil
+		}
+
+		data, err := json.Marshal(records)
+		if err != nil {
+			return fmt.Errorf("failed to marshal transaction: %w", err)
+		}
+
+		if err := s.append(opTransaction, data); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) NextArticleID(ctx context.Context) (articlesim.ArticleID, error) {
+	defer s.lock(ctx)()
+
+	if err := s.write(ctx, opAutoincrement, autoincrementData{Counter: counterArticles}); err != nil {
+		return 0, fmt.Errorf("failed to get autoicrement for articles: %w", err)
+	}
+
+	return s.state.NextArticleID(ctx)
+}
+
+func (s *Storage) CreateArticle(ctx context.Context, id articlesim.ArticleID, content string,
+	metadata articlesim.Metadata, tokens articlesim.Tokens, duplicates []articlesim.Duplicate, isUnique bool,
+	duplicateGroupID articlesim.DuplicateGroupID) error {
+	defer s.lock(ctx)()
+
+	data := createArticleData{
+		ID:               id,
+		Content:          content,
+		Title:            metadata.Title,
+		SourceURL:        metadata.SourceURL,
+		Author:           metadata.Author,
+		PublishedAt:      metadata.PublishedAt,
+		Tags:             metadata.Tags,
+		Words:            tokens.Words,
+		Language:         tokens.Language,
+		DuplicateIDs:     articlesim.DuplicateIDsOf(duplicates),
+		Duplicates:       fromModelDuplicates(duplicates),
+		IsUnique:         isUnique,
+		DuplicateGroupID: duplicateGroupID,
+	}
+
+	if err := s.write(ctx, opCreateArticle, data); err != nil {
+		return fmt.Errorf("failed to insert article: %w", err)
+	}
+
+	return s.state.CreateArticle(ctx, id, content, metadata, tokens, duplicates, isUnique, duplicateGroupID)
+}
+
+// CreateArticles stores the new articles with their index keys and duplicate groups in the transaction, so they
+// are written to the log as a single record.
+func (s *Storage) CreateArticles(ctx context.Context, articles []articlesim.IndexedArticle) error {
+	return s.WithTransaction(ctx, func(ctx context.Context) error {
+		for _, art := range articles {
+			if err := s.CreateArticle(ctx, art.ID, art.Content, art.Metadata, art.Tokens, art.Duplicates,
+				art.IsUnique, art.DuplicateGroupID); err != nil {
+				return err
+			}
+
+			if err := s.IndexArticle(ctx, art.ID, art.Keys); err != nil {
+				return err
+			}
+
+			if err := s.CreateDuplicateGroup(ctx, art.DuplicateGroupID, art.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) UpdateArticle(ctx context.Context, id articlesim.ArticleID,
+	duplicates []articlesim.Duplicate) error {
+	defer s.lock(ctx)()
+
+	if _, err := s.state.ArticleByID(ctx, id); err != nil {
+		return fmt.Errorf("failed to update article=%d: %w", id, err)
+	}
+
+	data := updateArticleData{
+		ID:           id,
+		DuplicateIDs: articlesim.DuplicateIDsOf(duplicates),
+		Duplicates:   fromModelDuplicates(duplicates),
+	}
+
+	if err := s.write(ctx, opUpdateArticle, data); err != nil {
+		return fmt.Errorf("failed to update article: %w", err)
+	}
+
+	return s.state.UpdateArticle(ctx, id, duplicates)
+}
+
+func (s *Storage) RegroupArticle(ctx context.Context, id articlesim.ArticleID, duplicates []articlesim.Duplicate,
+	isUnique bool, duplicateGroupID articlesim.DuplicateGroupID) error {
+	defer s.lock(ctx)()
+
+	if _, err := s.state.ArticleByID(ctx, id); err != nil {
+		return fmt.Errorf("failed to regroup article=%d: %w", id, err)
+	}
+
+	data := regroupArticleData{
+		ID:               id,
+		DuplicateIDs:     articlesim.DuplicateIDsOf(duplicates),
+		Duplicates:       fromModelDuplicates(duplicates),
+		IsUnique:         isUnique,
+		DuplicateGroupID: duplicateGroupID,
+	}
+
+	if err := s.write(ctx, opRegroupArticle, data); err != nil {
+		return fmt.Errorf("failed to regroup article: %w", err)
+	}
+
+	return s.state.RegroupArticle(ctx, id, duplicates, isUnique, duplicateGroupID)
+}
+
+func (s *Storage) DeleteArticle(ctx context.Context, id articlesim.ArticleID) error {
+	defer s.lock(ctx)()
+
+	if _, err := s.state.ArticleByID(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete article=%d: %w", id, err)
+	}
+
+	if err := s.write(ctx, opDeleteArticle, deleteArticleData{ID: id}); err != nil {
+		return fmt.Errorf("failed to delete article: %w", err)
+	}
+
+	return s.state.DeleteArticle(ctx, id)
+}
+
+func (s *Storage) ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.ArticleByID(ctx, id)
+}
+
+// ForEachArticle copies articles under the lock and calls fn without it, so fn may change the storage.
+func (s *Storage) ForEachArticle(ctx context.Context, fn func(art articlesim.Article) error) error {
+	var articles []articlesim.Article
+
+	unlock := s.rlock(ctx)
+	err := s.state.ForEachArticle(ctx, func(art articlesim.Article) error {
+		articles = append(articles, art)
+
+		return nil
+	})
+	unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for _, art := range articles {
+		if err := fn(art); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SnapshotArticles copies articles between transactions, so the snapshot has no changes of a running one.
+func (s *Storage) SnapshotArticles(ctx context.Context, fn func(art articlesim.Article) error) error {
+	var articles []articlesim.Article
+
+	unlock := s.rlock(ctx)
+	err := s.state.SnapshotArticles(ctx, func(art articlesim.Article) error {
+		articles = append(articles, art)
+
+		return nil
+	})
+	unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for _, art := range articles {
+		if err := fn(art); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) UniqueArticles(ctx context.Context, filter articlesim.ArticleFilter, after articlesim.ArticleID,
+	limit int) ([]articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.UniqueArticles(ctx, filter, after, limit)
+}
+
+func (s *Storage) ArticlesByDuplicateGroup(ctx context.Context,
+	duplicateGroupID articlesim.DuplicateGroupID) ([]articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.ArticlesByDuplicateGroup(ctx, duplicateGroupID)
+}
+
+func (s *Storage) NextDuplicateGroupID(ctx context.Context) (articlesim.DuplicateGroupID, error) {
+	defer s.lock(ctx)()
+
+	if err := s.write(ctx, opAutoincrement, autoincrementData{Counter: counterDuplicateGroups}); err != nil {
+		return 0, fmt.Errorf("failed to get autoicrement for duplicate groups: %w", err)
+	}
+
+	return s.state.NextDuplicateGroupID(ctx)
+}
+
+func (s *Storage) CreateDuplicateGroup(ctx context.Context, id articlesim.DuplicateGroupID,
+	articleID articlesim.ArticleID) error {
+	defer s.lock(ctx)()
+
+	data := createDuplicateGroupData{
+		ID:        id,
+		ArticleID: articleID,
+	}
+
+	if err := s.write(ctx, opCreateDuplicateGroup, data); err != nil {
+		return fmt.Errorf("failed to insert duplicate group: %w", err)
+	}
+
+	return s.state.CreateDuplicateGroup(ctx, id, articleID)
+}
+
+func (s *Storage) DuplicateGroups(ctx context.Context, after articlesim.DuplicateGroupID,
+	limit int) ([]articlesim.DuplicateGroupResp, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.DuplicateGroups(ctx, after, limit)
+}
+
+func (s *Storage) MergeDuplicateGroups(ctx context.Context, id articlesim.DuplicateGroupID,
+	mergedIDs []articlesim.DuplicateGroupID) error {
+	defer s.lock(ctx)()
+
+	data := mergeDuplicateGroupsData{
+		ID:        id,
+		MergedIDs: mergedIDs,
+	}
+
+	if err := s.write(ctx, opMergeDuplicateGroups, data); err != nil {
+		return fmt.Errorf("failed to merge duplicate groups: %w", err)
+	}
+
+	return s.state.MergeDuplicateGroups(ctx, id, mergedIDs)
+}
+
+func (s *Storage) IndexArticle(ctx context.Context, id articlesim.ArticleID, keys []uint64) error {
+	defer s.lock(ctx)()
+
+	data := indexArticleData{
+		ID:   id,
+		Keys: keys,
+	}
+
+	if err := s.write(ctx, opIndexArticle, data); err != nil {
+		return fmt.Errorf("failed to insert lsh keys: %w", err)
+	}
+
+	return s.state.IndexArticle(ctx, id, keys)
+}
+
+func (s *Storage) ReindexArticle(ctx context.Context, id articlesim.ArticleID, tokens articlesim.Tokens,
+	keys []uint64) error {
+	defer s.lock(ctx)()
+
+	if _, err := s.state.ArticleByID(ctx, id); err != nil {
+		return fmt.Errorf("failed to reindex article=%d: %w", id, err)
+	}
+
+	data := reindexArticleData{
+		ID:       id,
+		Words:    tokens.Words,
+		Language: tokens.Language,
+		Keys:     keys,
+	}
+
+	if err := s.write(ctx, opReindexArticle, data); err != nil {
+		return fmt.Errorf("failed to reindex article: %w", err)
+	}
+
+	return s.state.ReindexArticle(ctx, id, tokens, keys)
+}
+
+func (s *Storage) IndexParams(ctx context.Context) (string, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.IndexParams(ctx)
+}
+
+func (s *Storage) SetIndexParams(ctx context.Context, params string) error {
+	defer s.lock(ctx)()
+
+	if err := s.write(ctx, opSetIndexParams, indexParamsData{Params: params}); err != nil {
+		return fmt.Errorf("failed to set index params: %w", err)
+	}
+
+	return s.state.SetIndexParams(ctx, params)
+}
+
+func (s *Storage) ChangeSequence(ctx context.Context) (int64, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.ChangeSequence(ctx)
+}
+
+func (s *Storage) ChangedArticleIDs(ctx context.Context, after int64) ([]articlesim.ArticleID, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.ChangedArticleIDs(ctx, after)
+}
+
+func (s *Storage) CandidateArticles(ctx context.Context, keys []uint64) ([]articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	return s.state.CandidateArticles(ctx, keys)
+}
+
+// write appends the operation to the log and flushes it to the disk. Inside the transaction the operation is
+// buffered until the commit.
+func (s *Storage) write(ctx context.Context, op operation, data interface{}) error {
+	mdata, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	if s.inTransaction(ctx) {
+		s.tx = append(s.tx, record{
+			Sequence:  0,
+			Operation: op,
+			Data:      mdata,
+		})
+
+		return nil
+	}
+
+	return s.append(op, mdata)
+}
+
+// append writes the record with the next sequence to the log.
+func (s *Storage) append(op operation, data json.RawMessage) error {
+	rec, err := json.Marshal(record{
+		Sequence:  s.sequence + 1,
+		Operation: op,
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	if _, err := s.log.Write(append(rec, '\n')); err != nil {
+		return fmt.Errorf("failed to write log: %w", err)
+	}
+
+	if err := s.log.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log: %w", err)
+	}
+
+	s.sequence++
+	s.records++
+
+	if s.records >= compactRecords {
+		if err := s.compact(); err != nil {
+			log.Printf("failed to compact log: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// lock locks the storage unless the context belongs to the transaction holding the lock already.
+func (s *Storage) lock(ctx context.Context) func() {
+	if s.inTransaction(ctx) {
+		return func() {}
+	}
+
+	s.mu.Lock()
+
+	return s.mu.Unlock
+}
+
+// rlock locks the storage for a read unless the context belongs to the transaction holding the lock already.
+func (s *Storage) rlock(ctx context.Context) func() {
+	if s.inTransaction(ctx) {
+		return func() {}
+	}
+
+	s.mu.RLock()
+
+	return s.mu.RUnlock
+}
+
+func (s *Storage) inTransaction(ctx context.Context) bool {
+	tx, ok := ctx.Value(txKey{}).(*Storage)
+
+	return ok && tx == s
+}
+
+// apply replays the record on the state.
+func (s *Storage) apply(rec record) error {
+	ctx := context.Background()
+
+	switch rec.Operation {
+	case opAutoincrement:
+		return s.applyAutoincrement(ctx, rec.Data)
+	case opCreateArticle, opUpdateArticle, opRegroupArticle, opDeleteArticle, opReindexArticle:
+		return s.applyArticle(ctx, rec)
+	case opCreateDuplicateGroup:
+		data := createDuplicateGroupData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal duplicate group: %w", err)
+		}
+
+		return s.state.CreateDuplicateGroup(ctx, data.ID, data.ArticleID)
+	case opMergeDuplicateGroups:
+		data := mergeDuplicateGroupsData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal merge duplicate groups: %w", err)
+		}
+
+		return s.state.MergeDuplicateGroups(ctx, data.ID, data.MergedIDs)
+	case opIndexArticle:
+		data := indexArticleData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal index article: %w", err)
+		}
+
+		return s.state.IndexArticle(ctx, data.ID, data.Keys)
+	case opSetIndexParams:
+		data := indexParamsData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal index params: %w", err)
+		}
+
+		return s.state.SetIndexParams(ctx, data.Params)
+	case opTransaction:
+		return s.applyTransaction(rec.Data)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownOperation, rec.Operation)
+	}
+}
+
+// applyArticle replays the record changing an article.
+func (s *Storage) applyArticle(ctx context.Context, rec record) error {
+	switch rec.Operation {
+	case opCreateArticle:
+		data := createArticleData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal article: %w", err)
+		}
+
+		metadata := articlesim.Metadata{
+			Title:       data.Title,
+			SourceURL:   data.SourceURL,
+			Author:      data.Author,
+			PublishedAt: data.PublishedAt,
+			Tags:        data.Tags,
+		}
+		tokens := articlesim.Tokens{
+			Words:    data.Words,
+			Language: data.Language,
+		}
+
+		return s.state.CreateArticle(ctx, data.ID, data.Content, metadata, tokens,
+			toModelDuplicates(data.DuplicateIDs, data.Duplicates), data.IsUnique, data.DuplicateGroupID)
+	case opUpdateArticle:
+		data := updateArticleData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal update article: %w", err)
+		}
+
+		return s.state.UpdateArticle(ctx, data.ID, toModelDuplicates(data.DuplicateIDs, data.Duplicates))
+	case opRegroupArticle:
+		data := regroupArticleData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal regroup article: %w", err)
+		}
+
+		return s.state.RegroupArticle(ctx, data.ID, toModelDuplicates(data.DuplicateIDs, data.Duplicates),
+			data.IsUnique, data.DuplicateGroupID)
+	case opDeleteArticle:
+		data := deleteArticleData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal delete article: %w", err)
+		}
+
+		return s.state.DeleteArticle(ctx, data.ID)
+	case opReindexArticle:
+		data := reindexArticleData{}
+		if err := json.Unmarshal(rec.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal reindex article: %w", err)
+		}
+
+		tokens := articlesim.Tokens{
+			Words:    data.Words,
+			Language: data.Language,
+		}
+
+		return s.state.ReindexArticle(ctx, data.ID, tokens, data.Keys)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownOperation, rec.Operation)
+	}
+}
+
+// toModelDuplicates returns links of the record. Duplicate ids without links are read from records written before
+// scores were kept.
+func toModelDuplicates(ids []articlesim.ArticleID, duplicates []duplicateData) []articlesim.Duplicate {
+	if len(duplicates) == 0 {
+		return articlesim.UnscoredDuplicates(ids)
+	}
+
+	res := make([]articlesim.Duplicate, 0, len(duplicates))
+	for _, d := range duplicates {
+		res = append(res, articlesim.Duplicate{
+			ID:        d.ID,
+			Score:     d.Score,
+			Algorithm: d.Algorithm,
+			Threshold: d.Threshold,
+		})
+	}
+
+	return res
+}
+
+func fromModelDuplicates(duplicates []articlesim.Duplicate) []duplicateData {
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	res := make([]duplicateData, 0, len(duplicates))
+	for _, d := range duplicates {
+		res = append(res, duplicateData{
+			ID:        d.ID,
+			Score:     d.Score,
+			Algorithm: d.Algorithm,
+			Threshold: d.Threshold,
+		})
+	}
+
+	return res
+}
+
+func (s *Storage) applyTransaction(content json.RawMessage) error {
+	records := make([]record, 0)
+	if err := json.Unmarshal(content, &records); err != nil {
+		return fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	for _, rec := range records {
+		if err := s.apply(rec); err != nil {
+			return fmt.Errorf("failed to apply transaction %s: %w", rec.Operation, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) applyAutoincrement(ctx context.Context, content json.RawMessage) error {
+	data := autoincrementData{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return fmt.Errorf("failed to unmarshal autoincrement: %w", err)
+	}
+
+	var err error
+
+	switch data.Counter {
+	case counterArticles:
+		_, err = s.state.NextArticleID(ctx)
+	case counterDuplicateGroups:
+		_, err = s.state.NextDuplicateGroupID(ctx)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownCounter, data.Counter)
+	}
+
+	return err
+}
+
+// restore reads the snapshot and replays log records written after it. A broken last record is the trace of
+// an interrupted write, so it is skipped.
+func (s *Storage) restore() error {
+	snap := snapshot{
+		Sequence: 0,
+		State:    memory.New(),
+	}
+
+	content, err := ioutil.ReadFile(s.path(snapshotFileName))
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	default:
+		if err := json.Unmarshal(content, &snap); err != nil {
+			return fmt.Errorf("failed to unmarshal snapshot: %w", err)
+		}
+	}
+
+	s.state = snap.State
+	s.sequence = snap.Sequence
+
+	file, err := os.Open(s.path(logFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("file close failed: %v", err)
+		}
+	}()
+
+	return s.replay(bufio.NewReader(file))
+}
+
+func (s *Storage) replay(reader *bufio.Reader) error {
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) != 0 {
+				log.Printf("skip incomplete log record: %s", line)
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+
+		rec := record{}
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("failed to unmarshal log record: %w", err)
+		}
+
+		if rec.Sequence <= s.sequence {
+			continue
+		}
+
+		if err := s.apply(rec); err != nil {
+			return fmt.Errorf("failed to apply log record=%d: %w", rec.Sequence, err)
+		}
+
+		s.sequence = rec.Sequence
+	}
+}
+
+// compact writes the state to the snapshot file and truncates the log.
+// The snapshot is replaced atomically, records already in the snapshot are skipped on the next replay by sequence.
+func (s *Storage) compact() error {
+	content, err := json.Marshal(snapshot{
+		Sequence: s.sequence,
+		State:    s.state,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(s.dir, snapshotFileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(snapshotFileName)); err != nil {
+		return fmt.Errorf("failed to rename snapshot: %w", err)
+	}
+
+	if s.log != nil {
+		if err := s.log.Close(); err != nil {
+			return fmt.Errorf("failed to close log: %w", err)
+		}
+	}
+
+	s.log, err = os.OpenFile(s.path(logFileName), os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+
+	s.records = 0
+
+	return nil
+}
+
+func (s *Storage) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
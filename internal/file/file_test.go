@@ -0,0 +1,406 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+)
+
+func TestOpen_Locked(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	_, err = Open(dir)
+	assert.True(t, errors.Is(err, ErrLocked))
+
+	require.NoError(t, st.Close())
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	id, err := st.NextArticleID(ctx)
+	require.NoError(t, err)
+	gid, err := st.NextDuplicateGroupID(ctx)
+	require.NoError(t, err)
+	tokens := articlesim.Tokens{Words: []string{"hello"}, Language: "en"}
+	metadata := articlesim.Metadata{
+		Title:       "Hello",
+		SourceURL:   "https://example.com/hello",
+		Author:      "John",
+		PublishedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tags:        []string{"news"},
+	}
+	require.NoError(t, st.CreateArticle(ctx, id, "hello", metadata, tokens, nil, true, gid))
+	require.NoError(t, st.CreateDuplicateGroup(ctx, gid, id))
+	require.NoError(t, st.IndexArticle(ctx, id, []uint64{1, 2}))
+	require.NoError(t, st.SetIndexParams(ctx, "params"))
+	require.NoError(t, st.Close())
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+
+	art, err := st.ArticleByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.Article{
+		ID:               1,
+		Content:          "hello",
+		Metadata:         metadata,
+		Tokens:           tokens,
+		DuplicateIDs:     nil,
+		IsUnique:         true,
+		DuplicateGroupID: 1,
+	}, art)
+
+	candidates, err := st.CandidateArticles(ctx, []uint64{2, 3})
+	require.NoError(t, err)
+	assert.Len(t, candidates, 1)
+
+	params, err := st.IndexParams(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "params", params)
+
+	nextID, err := st.NextArticleID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(2), nextID)
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_SkipsIncompleteRecord(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	_, err = st.NextArticleID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+
+	logFile, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_WRONLY|os.O_APPEND, filePerm)
+	require.NoError(t, err)
+	_, err = logFile.WriteString(`{"seq":2,"op":"autoincr`)
+	require.NoError(t, err)
+	require.NoError(t, logFile.Close())
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+
+	id, err := st.NextArticleID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(2), id)
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_ReplaysLogAfterSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	_, err = st.NextArticleID(ctx)
+	require.NoError(t, err)
+
+	logContent, err := ioutil.ReadFile(filepath.Join(dir, logFileName))
+	require.NoError(t, err)
+	require.NoError(t, st.compact())
+	require.NoError(t, st.Close())
+
+	// the log is not truncated when compaction is interrupted after the snapshot is written
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, logFileName), logContent, filePerm))
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+
+	id, err := st.NextArticleID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(2), id)
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_ArticleByID_NotFound(t *testing.T) {
+	st, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = st.ArticleByID(context.Background(), 1)
+
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_DeleteArticle_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, st.CreateArticle(ctx, 1, "hello", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+	require.NoError(t, st.CreateDuplicateGroup(ctx, 1, 1))
+	require.NoError(t, st.IndexArticle(ctx, 1, []uint64{1}))
+	duplicates := []articlesim.Duplicate{{ID: 1, Score: 1, Algorithm: "levenshtein", Threshold: 0.95}}
+	require.NoError(t, st.CreateArticle(ctx, 2, "hello!", articlesim.Metadata{},
+		articlesim.Tokens{}, duplicates, false, 1))
+	require.NoError(t, st.CreateDuplicateGroup(ctx, 1, 2))
+	require.NoError(t, st.IndexArticle(ctx, 2, []uint64{1}))
+	require.NoError(t, st.DeleteArticle(ctx, 1))
+	require.NoError(t, st.RegroupArticle(ctx, 2, nil, true, 1))
+	require.NoError(t, st.Close())
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+
+	_, err = st.ArticleByID(ctx, 1)
+	assert.True(t, errors.Is(err, articlesim.ErrArticleNotFound))
+
+	candidates, err := st.CandidateArticles(ctx, []uint64{1})
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.Article{
+		{ID: 2, Content: "hello!", DuplicateIDs: nil, IsUnique: true, DuplicateGroupID: 1},
+	}, candidates)
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_Duplicates_SurviveRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	duplicates := []articlesim.Duplicate{{ID: 1, Score: 0.96, Algorithm: "levenshtein", Threshold: 0.95}}
+	require.NoError(t, st.CreateArticle(ctx, 1, "hello", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+	require.NoError(t, st.CreateArticle(ctx, 2, "hello!", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 2))
+	require.NoError(t, st.UpdateArticle(ctx, 2, duplicates))
+	require.NoError(t, st.Close())
+
+	// articles stored before scores were kept have only duplicate ids
+	logFile, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_WRONLY|os.O_APPEND, filePerm)
+	require.NoError(t, err)
+	_, err = logFile.WriteString(`{"seq":4,"op":"update_article","data":{"id":1,"duplicate_ids":[2]}}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, logFile.Close())
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+
+	art, err := st.ArticleByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{1}, art.DuplicateIDs)
+	assert.Equal(t, duplicates, art.Duplicates)
+
+	art, err = st.ArticleByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.Duplicate{{ID: 2, Score: 0, Algorithm: "", Threshold: 0}}, art.Duplicates)
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_ReindexArticle_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	tokens := articlesim.Tokens{Words: []string{"hello"}, Language: "en"}
+
+	require.NoError(t, st.CreateArticle(ctx, 1, "hello", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+	require.NoError(t, st.IndexArticle(ctx, 1, []uint64{1}))
+	require.NoError(t, st.ReindexArticle(ctx, 1, tokens, []uint64{2}))
+	require.NoError(t, st.Close())
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+
+	art, err := st.ArticleByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, tokens, art.Tokens)
+
+	candidates, err := st.CandidateArticles(ctx, []uint64{1})
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+
+	candidates, err = st.CandidateArticles(ctx, []uint64{2})
+	require.NoError(t, err)
+	assert.Len(t, candidates, 1)
+	require.NoError(t, st.Close())
+}
+
+func TestStorage_WithTransaction(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	for name, tc := range map[string]struct {
+		err             error
+		expectedFound   bool
+		expectedRecords int
+	}{
+		"when transaction is committed": {
+			err:             nil,
+			expectedFound:   true,
+			expectedRecords: 1,
+		},
+		"when transaction fails": {
+			err:             errFailed,
+			expectedFound:   false,
+			expectedRecords: 0,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			st, err := Open(dir)
+			require.NoError(t, err)
+
+			err = st.WithTransaction(ctx, func(ctx context.Context) error {
+				id, err := st.NextArticleID(ctx)
+				require.NoError(t, err)
+				require.NoError(t, st.CreateArticle(ctx, id, "hello", articlesim.Metadata{},
+					articlesim.Tokens{}, nil, true, 1))
+
+				return tc.err
+			})
+			assert.True(t, errors.Is(err, tc.err))
+
+			_, err = st.ArticleByID(ctx, 1)
+			assert.Equal(t, tc.expectedFound, err == nil)
+			require.NoError(t, st.Close())
+
+			// the transaction is a single log record
+			logContent, err := ioutil.ReadFile(filepath.Join(dir, logFileName))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRecords, bytes.Count(logContent, []byte("\n")))
+
+			st, err = Open(dir)
+			require.NoError(t, err)
+
+			_, err = st.ArticleByID(ctx, 1)
+			assert.Equal(t, tc.expectedFound, err == nil)
+			require.NoError(t, st.Close())
+		})
+	}
+}
+
+func TestStorage_WithTransaction_NoDirtyReads(t *testing.T) {
+	const transactions = 20
+
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+
+	st, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, st.Close()) }()
+
+	done := make(chan struct{})
+	read := make(chan bool)
+
+	go func() {
+		found := false
+
+		for {
+			select {
+			case <-done:
+				read <- found
+
+				return
+			default:
+			}
+
+			if _, err := st.ArticleByID(ctx, 1); err == nil {
+				found = true
+			}
+		}
+	}()
+
+	for i := 0; i < transactions; i++ {
+		err := st.WithTransaction(ctx, func(ctx context.Context) error {
+			require.NoError(t, st.CreateArticle(ctx, 1, "hello", articlesim.Metadata{}, articlesim.Tokens{}, nil,
+				true, 1))
+
+			time.Sleep(time.Millisecond)
+
+			return errFailed
+		})
+		require.True(t, errors.Is(err, errFailed))
+	}
+
+	close(done)
+	assert.False(t, <-read)
+}
+
+func TestStorage_SwapStaging_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := Open(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, st.CreateArticle(ctx, 1, "hello", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+	require.NoError(t, st.CreateArticle(ctx, 2, "hello!", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 2))
+
+	staging, err := st.Staging()
+	require.NoError(t, err)
+
+	duplicates := []articlesim.Duplicate{{ID: 1, Score: 0.5, Algorithm: "jaccard", Threshold: 0.4}}
+	require.NoError(t, staging.CreateArticle(ctx, 1, "hello", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+	require.NoError(t, staging.CreateArticle(ctx, 2, "hello!", articlesim.Metadata{},
+		articlesim.Tokens{}, duplicates, false, 1))
+
+	errFailed := errors.New("failed")
+	require.True(t, errors.Is(st.SwapStaging(ctx, func(ctx context.Context) error { return errFailed }), errFailed))
+
+	art, err := st.ArticleByID(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, art.IsUnique)
+
+	// the catch-up reads the storage while changes wait
+	require.NoError(t, st.SwapStaging(ctx, func(ctx context.Context) error {
+		art, err := st.ArticleByID(ctx, 2)
+		if err != nil {
+			return err
+		}
+
+		return staging.CreateArticle(ctx, 3, art.Content+"!", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 3)
+	}))
+	assert.True(t, errors.Is(st.SwapStaging(ctx, func(ctx context.Context) error { return nil }), ErrNoStaging))
+	require.NoError(t, st.Close())
+
+	_, err = os.Stat(filepath.Join(dir, stagingDirName))
+	assert.True(t, os.IsNotExist(err))
+
+	st, err = Open(dir)
+	require.NoError(t, err)
+
+	art, err = st.ArticleByID(ctx, 2)
+	require.NoError(t, err)
+	assert.False(t, art.IsUnique)
+	assert.Equal(t, duplicates, art.Duplicates)
+
+	art, err = st.ArticleByID(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "hello!!", art.Content)
+	require.NoError(t, st.Close())
+}
@@ -0,0 +1,32 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is returned when the file is opened by another process without sharing.
+const errorSharingViolation syscall.Errno = 32
+
+// lockFile opens the lock file without sharing, so other processes cannot open it until the file is closed, also
+// by the exit of the process.
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert lock file=%s: %w", path, err)
+	}
+
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS,
+		syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, ErrLocked
+		}
+
+		return nil, fmt.Errorf("failed to open lock file=%s: %w", path, err)
+	}
+
+	return os.NewFile(uintptr(h), path), nil
+}
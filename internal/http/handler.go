@@ -1,64 +1,167 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	nethttp "net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/swag"
 
 	articlesim "github.com/devchallenge/article-similarity/internal"
+	"github.com/devchallenge/article-similarity/internal/archive"
 	"github.com/devchallenge/article-similarity/internal/http/models"
 	"github.com/devchallenge/article-similarity/internal/http/restapi/operations"
 )
 
 const (
 	serverTimeout = 5 * time.Second
+
+	defaultSearchLimit = 10
+)
+
+var (
+	ErrInvalidCursor         = errors.New("invalid cursor")
+	ErrInvalidSource         = errors.New("invalid source")
+	ErrInvalidPublishedRange = errors.New("published_from is after published_to")
 )
 
 type ArticleServer interface {
-	CreateArticle(ctx context.Context, content string) (articlesim.Article, error)
+	CreateArticle(ctx context.Context, content string, metadata articlesim.Metadata) (articlesim.Article, error)
+	ImportArticles(ctx context.Context, inputs []articlesim.ArticleInput) ([]articlesim.Article, error)
 	ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error)
-	UniqueArticles(ctx context.Context) ([]articlesim.Article, error)
-	DuplicateGroups(ctx context.Context) ([]articlesim.DuplicateGroupResp, error)
+	UpdateArticle(ctx context.Context, id articlesim.ArticleID, content string) (articlesim.ArticleUpdate, error)
+	DeleteArticle(ctx context.Context, id articlesim.ArticleID) error
+	Compare(ctx context.Context, id, otherID articlesim.ArticleID) (articlesim.Comparison, error)
+	Search(ctx context.Context, content string, limit int) ([]articlesim.Match, error)
+	UniqueArticles(ctx context.Context, filter articlesim.ArticleFilter, cursor articlesim.ArticleID,
+		limit int) ([]articlesim.Article, articlesim.ArticleID, error)
+	DuplicateGroups(ctx context.Context, cursor articlesim.DuplicateGroupID,
+		limit int) ([]articlesim.DuplicateGroupResp, articlesim.DuplicateGroupID, error)
+	ExportArticles(ctx context.Context, fn func(art articlesim.Article) error) error
+}
+
+// Reclusterer recomputes duplicates of stored articles in the background.
+type Reclusterer interface {
+	Start() (articlesim.ReclusterStatus, error)
+	Status() articlesim.ReclusterStatus
 }
 
 type Handler struct {
-	article ArticleServer
+	article   ArticleServer
+	recluster Reclusterer
 }
 
-func New(article ArticleServer) *Handler {
+func New(article ArticleServer, recluster Reclusterer) *Handler {
 	return &Handler{
-		article: article,
+		article:   article,
+		recluster: recluster,
 	}
 }
 
 func (h *Handler) ConfigureHandlers(api *operations.ArticleSimilarityAPI) {
 	api.PostArticlesHandler = operations.PostArticlesHandlerFunc(h.PostArticles)
+	api.PostArticlesBatchHandler = operations.PostArticlesBatchHandlerFunc(h.PostArticlesBatch)
+	api.PostArticlesSearchHandler = operations.PostArticlesSearchHandlerFunc(h.PostArticlesSearch)
 	api.GetArticlesIDHandler = operations.GetArticlesIDHandlerFunc(h.GetArticleByID)
+	api.PutArticlesIDHandler = operations.PutArticlesIDHandlerFunc(h.PutArticle)
+	api.DeleteArticlesIDHandler = operations.DeleteArticlesIDHandlerFunc(h.DeleteArticle)
+	api.GetArticlesIDCompareOtherIDHandler = operations.GetArticlesIDCompareOtherIDHandlerFunc(h.GetComparison)
 	api.GetArticlesHandler = operations.GetArticlesHandlerFunc(h.GetUniqueArticles)
 	api.GetDuplicateGroupsHandler = operations.GetDuplicateGroupsHandlerFunc(h.GetDuplicateGroups)
+	api.GetExportHandler = operations.GetExportHandlerFunc(h.GetExport)
+	api.PostAdminReclusterHandler = operations.PostAdminReclusterHandlerFunc(h.PostRecluster)
+	api.GetAdminReclusterHandler = operations.GetAdminReclusterHandlerFunc(h.GetRecluster)
 }
 
 func (h *Handler) PostArticles(params operations.PostArticlesParams) middleware.Responder {
+	input, err := archive.ParseArticle(params.Body)
+	if err != nil {
+		return operations.NewPostArticlesBadRequest().WithPayload(&models.Error{
+			Message: swag.String(err.Error()),
+			Code:    0,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(params.HTTPRequest.Context(), serverTimeout)
+	defer cancel()
+
+	article, err := h.article.CreateArticle(ctx, input.Content, input.Metadata)
+	if err != nil {
+		return operations.NewPostArticlesInternalServerError()
+	}
+
+	return operations.NewPostArticlesCreated().WithPayload(archive.ModelsArticle(article))
+}
+
+// PostArticlesBatch imports articles in the background and streams their results. The import is bound to
+// the request, so it stops when the client disconnects. HTTP/2 requests get results while the body is read,
+// results of HTTP/1.x requests are held until the body is read.
+func (h *Handler) PostArticlesBatch(params operations.PostArticlesBatchParams) middleware.Responder {
+	results, w := io.Pipe()
+
+	go func() {
+		defer params.Body.Close()
+
+		body := &afterBody{body: params.Body, w: w, held: bytes.Buffer{}, read: params.HTTPRequest.ProtoMajor >= 2}
+
+		stored, err := archive.Import(params.HTTPRequest.Context(), h.article, body, body)
+		if err != nil {
+			log.Printf("failed to import articles after %d stored: %v", stored, err)
+		}
+
+		if err := body.flush(); err != nil {
+			log.Printf("failed to write import results: %v", err)
+		}
+
+		if err := w.Close(); err != nil {
+			log.Printf("failed to close import results: %v", err)
+		}
+	}()
+
+	return operations.NewPostArticlesBatchOK().WithPayload(results)
+}
+
+func (h *Handler) PostArticlesSearch(params operations.PostArticlesSearchParams) middleware.Responder {
 	content := *params.Body.Content
 	if content == "" {
-		return operations.NewPostArticlesBadRequest().WithPayload(&models.Error{
+		return operations.NewPostArticlesSearchBadRequest().WithPayload(&models.Error{
 			Message: swag.String("empty content"),
 			Code:    0,
 		})
 	}
 
+	limit := int(params.Body.Limit)
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
 	ctx, cancel := context.WithTimeout(params.HTTPRequest.Context(), serverTimeout)
 	defer cancel()
 
-	article, err := h.article.CreateArticle(ctx, content)
+	matches, err := h.article.Search(ctx, content, limit)
 	if err != nil {
-		return operations.NewPostArticlesInternalServerError()
+		return operations.NewPostArticlesSearchInternalServerError()
+	}
+
+	modelsMatches := make([]*models.Match, 0, len(matches))
+	for _, m := range matches {
+		modelsMatches = append(modelsMatches, &models.Match{
+			Article:     archive.ModelsArticle(m.Article),
+			Score:       swag.Float64(m.Score),
+			IsDuplicate: swag.Bool(m.IsDuplicate),
+		})
 	}
 
-	return operations.NewPostArticlesCreated().WithPayload(modelsArticle(article))
+	return operations.NewPostArticlesSearchOK().WithPayload(&operations.PostArticlesSearchOKBody{
+		Matches: modelsMatches,
+	})
 }
 
 func (h *Handler) GetArticleByID(params operations.GetArticlesIDParams) middleware.Responder {
@@ -75,33 +178,121 @@ func (h *Handler) GetArticleByID(params operations.GetArticlesIDParams) middlewa
 		return operations.NewGetArticlesIDInternalServerError()
 	}
 
-	return operations.NewGetArticlesIDOK().WithPayload(modelsArticle(article))
+	return operations.NewGetArticlesIDOK().WithPayload(archive.ModelsArticle(article))
+}
+
+func (h *Handler) PutArticle(params operations.PutArticlesIDParams) middleware.Responder {
+	content := *params.Body.Content
+	if content == "" {
+		return operations.NewPutArticlesIDBadRequest().WithPayload(&models.Error{
+			Message: swag.String("empty content"),
+			Code:    0,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(params.HTTPRequest.Context(), serverTimeout)
+	defer cancel()
+
+	update, err := h.article.UpdateArticle(ctx, articlesim.ArticleID(params.ID), content)
+
+	if errors.Is(err, articlesim.ErrArticleNotFound) {
+		return operations.NewPutArticlesIDNotFound()
+	}
+
+	if err != nil {
+		return operations.NewPutArticlesIDInternalServerError()
+	}
+
+	return operations.NewPutArticlesIDOK().WithPayload(&models.ArticleUpdate{
+		Article:             archive.ModelsArticle(update.Article),
+		AddedDuplicateIds:   modelsIDs(update.AddedDuplicateIDs),
+		RemovedDuplicateIds: modelsIDs(update.RemovedDuplicateIDs),
+	})
+}
+
+func (h *Handler) DeleteArticle(params operations.DeleteArticlesIDParams) middleware.Responder {
+	ctx, cancel := context.WithTimeout(params.HTTPRequest.Context(), serverTimeout)
+	defer cancel()
+
+	err := h.article.DeleteArticle(ctx, articlesim.ArticleID(params.ID))
+
+	if errors.Is(err, articlesim.ErrArticleNotFound) {
+		return operations.NewDeleteArticlesIDNotFound()
+	}
+
+	if err != nil {
+		return operations.NewDeleteArticlesIDInternalServerError()
+	}
+
+	return operations.NewDeleteArticlesIDNoContent()
+}
+
+func (h *Handler) GetComparison(params operations.GetArticlesIDCompareOtherIDParams) middleware.Responder {
+	ctx, cancel := context.WithTimeout(params.HTTPRequest.Context(), serverTimeout)
+	defer cancel()
+
+	comparison, err := h.article.Compare(ctx, articlesim.ArticleID(params.ID), articlesim.ArticleID(params.OtherID))
+
+	if errors.Is(err, articlesim.ErrArticleNotFound) {
+		return operations.NewGetArticlesIDCompareOtherIDNotFound()
+	}
+
+	if err != nil {
+		return operations.NewGetArticlesIDCompareOtherIDInternalServerError()
+	}
+
+	return operations.NewGetArticlesIDCompareOtherIDOK().WithPayload(modelsComparison(comparison))
 }
 
 func (h *Handler) GetUniqueArticles(params operations.GetArticlesParams) middleware.Responder {
+	cursor, err := parseCursor(params.Cursor)
+	if err != nil {
+		return operations.NewGetArticlesBadRequest().WithPayload(&models.Error{
+			Message: swag.String(err.Error()),
+			Code:    0,
+		})
+	}
+
+	filter, err := parseArticleFilter(params)
+	if err != nil {
+		return operations.NewGetArticlesBadRequest().WithPayload(&models.Error{
+			Message: swag.String(err.Error()),
+			Code:    0,
+		})
+	}
+
 	ctx, cancel := context.WithTimeout(params.HTTPRequest.Context(), serverTimeout)
 	defer cancel()
 
-	articles, err := h.article.UniqueArticles(ctx)
+	articles, next, err := h.article.UniqueArticles(ctx, filter, articlesim.ArticleID(cursor), int(*params.Limit))
 	if err != nil {
 		return operations.NewGetArticlesInternalServerError()
 	}
 
 	modelsArticles := make([]*models.Article, 0, len(articles))
 	for _, article := range articles {
-		modelsArticles = append(modelsArticles, modelsArticle(article))
+		modelsArticles = append(modelsArticles, archive.ModelsArticle(article))
 	}
 
 	return operations.NewGetArticlesOK().WithPayload(&operations.GetArticlesOKBody{
-		Articles: modelsArticles,
+		Articles:   modelsArticles,
+		NextCursor: formatCursor(int(next)),
 	})
 }
 
 func (h *Handler) GetDuplicateGroups(params operations.GetDuplicateGroupsParams) middleware.Responder {
+	cursor, err := parseCursor(params.Cursor)
+	if err != nil {
+		return operations.NewGetDuplicateGroupsBadRequest().WithPayload(&models.Error{
+			Message: swag.String(err.Error()),
+			Code:    0,
+		})
+	}
+
 	ctx, cancel := context.WithTimeout(params.HTTPRequest.Context(), serverTimeout)
 	defer cancel()
 
-	groups, err := h.article.DuplicateGroups(ctx)
+	groups, next, err := h.article.DuplicateGroups(ctx, articlesim.DuplicateGroupID(cursor), int(*params.Limit))
 	if err != nil {
 		return operations.NewGetDuplicateGroupsInternalServerError()
 	}
@@ -120,20 +311,152 @@ func (h *Handler) GetDuplicateGroups(params operations.GetDuplicateGroupsParams)
 
 	return operations.NewGetDuplicateGroupsOK().WithPayload(&operations.GetDuplicateGroupsOKBody{
 		DuplicateGroups: modelsDuplicateGroups,
+		NextCursor:      formatCursor(int(next)),
 	})
 }
 
-func modelsArticle(article articlesim.Article) *models.Article {
-	const maxDuplicates = 100
+// GetExport streams articles exported in the background. A failed export aborts the response, so clients do not
+// take the truncated output for the complete one.
+func (h *Handler) GetExport(params operations.GetExportParams) middleware.Responder {
+	format := *params.Format
+	articles, w := io.Pipe()
+
+	go func() {
+		exported, err := archive.Export(params.HTTPRequest.Context(), h.article, format, w)
+		if err != nil {
+			log.Printf("failed to export articles after %d exported: %v", exported, err)
+			w.CloseWithError(nethttp.ErrAbortHandler)
+
+			return
+		}
+
+		if err := w.Close(); err != nil {
+			log.Printf("failed to close exported articles: %v", err)
+		}
+	}()
+
+	return operations.NewGetExportOK().WithContentType(archive.ContentTypes[format]).WithPayload(articles)
+}
+
+func (h *Handler) PostRecluster(params operations.PostAdminReclusterParams) middleware.Responder {
+	status, err := h.recluster.Start()
+
+	if errors.Is(err, articlesim.ErrReclusterRunning) {
+		return operations.NewPostAdminReclusterConflict().WithPayload(&models.Error{
+			Message: swag.String(err.Error()),
+			Code:    0,
+		})
+	}
+
+	if err != nil {
+		return operations.NewPostAdminReclusterInternalServerError()
+	}
+
+	return operations.NewPostAdminReclusterAccepted().WithPayload(modelsReclusterStatus(status))
+}
+
+func (h *Handler) GetRecluster(params operations.GetAdminReclusterParams) middleware.Responder {
+	return operations.NewGetAdminReclusterOK().WithPayload(modelsReclusterStatus(h.recluster.Status()))
+}
+
+// parseCursor returns the id after which the page starts. The absent cursor is the first page.
+func parseCursor(cursor *string) (int, error) {
+	if cursor == nil {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(*cursor)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidCursor, *cursor)
+	}
+
+	return id, nil
+}
+
+// parseArticleFilter returns the filter of unique articles. Absent parameters select all articles.
+func parseArticleFilter(params operations.GetArticlesParams) (articlesim.ArticleFilter, error) {
+	filter := articlesim.ArticleFilter{
+		Source:        "",
+		Tag:           swag.StringValue(params.Tag),
+		PublishedFrom: time.Time{},
+		PublishedTo:   time.Time{},
+	}
+
+	if params.Source != nil {
+		filter.Source = articlesim.SourceOf(*params.Source)
+		if filter.Source == "" {
+			return articlesim.ArticleFilter{}, fmt.Errorf("%w: %s", ErrInvalidSource, *params.Source)
+		}
+	}
+
+	if params.PublishedFrom != nil {
+		filter.PublishedFrom = time.Time(*params.PublishedFrom)
+	}
 
-	duplicateIDs := make([]int64, 0, maxDuplicates)
-	for _, id := range article.DuplicateIDs {
-		duplicateIDs = append(duplicateIDs, int64(id))
+	if params.PublishedTo != nil {
+		filter.PublishedTo = time.Time(*params.PublishedTo)
 	}
 
-	return &models.Article{
-		ID:                  models.ArticleID(int64(article.ID)),
-		Content:             swag.String(article.Content),
-		DuplicateArticleIds: duplicateIDs,
+	if !filter.PublishedTo.IsZero() && filter.PublishedFrom.After(filter.PublishedTo) {
+		return articlesim.ArticleFilter{}, ErrInvalidPublishedRange
+	}
+
+	return filter, nil
+}
+
+// formatCursor returns the cursor of the page starting after the id. Zero id means there is no page.
+func formatCursor(id int) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(id)
+}
+
+func modelsIDs(ids []articlesim.ArticleID) []int64 {
+	res := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, int64(id))
+	}
+
+	return res
+}
+
+func modelsComparison(comparison articlesim.Comparison) *models.Comparison {
+	editScript := make([]*models.EditOperation, 0, len(comparison.EditScript))
+	for _, op := range comparison.EditScript {
+		editScript = append(editScript, &models.EditOperation{
+			Op:            swag.String(op.Operation),
+			Position:      swag.Int64(int64(op.Position)),
+			OtherPosition: swag.Int64(int64(op.OtherPosition)),
+			Word:          op.Word,
+			OtherWord:     op.OtherWord,
+		})
+	}
+
+	return &models.Comparison{
+		ArticleID:        models.ArticleID(int64(comparison.ArticleID)),
+		OtherArticleID:   models.ArticleID(int64(comparison.OtherArticleID)),
+		Language:         swag.String(comparison.Language),
+		OtherLanguage:    swag.String(comparison.OtherLanguage),
+		Words:            comparison.Words,
+		OtherWords:       comparison.OtherWords,
+		Distance:         swag.Int64(int64(comparison.Distance)),
+		LevenshteinScore: swag.Float64(comparison.LevenshteinScore),
+		Similarity:       swag.Float64(comparison.Similarity),
+		Threshold:        swag.Float64(comparison.Threshold),
+		IsSimilar:        swag.Bool(comparison.IsSimilar),
+		EditScript:       editScript,
+	}
+}
+
+func modelsReclusterStatus(status articlesim.ReclusterStatus) *models.ReclusterStatus {
+	return &models.ReclusterStatus{
+		State:         swag.String(string(status.State)),
+		Processed:     swag.Int64(int64(status.Processed)),
+		Reclustered:   swag.Int64(int64(status.Reclustered)),
+		Deleted:       swag.Int64(int64(status.Deleted)),
+		LastArticleID: models.ArticleID(int64(status.LastArticleID)),
+		Error:         status.Error,
 	}
 }
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// heldResultsLimit is the size of results held until the request body is read.
+const heldResultsLimit = 8 << 20
+
+var ErrHeldResultsLimited = errors.New("held import results exceed the limit")
+
+// afterBody holds writes to the response until the request body is read. HTTP/1.x server discards the unread request
+// body once the response is flushed, so results of the first batches are buffered until the last line is read.
+// Once the held results exceed heldResultsLimit, the body is not read further, so the import stops after the stored
+// batches, the last result has the first line which is not imported, and the held buffer grows by at most one more
+// batch.
+type afterBody struct {
+	body io.Reader
+	w    io.Writer
+	held bytes.Buffer
+	read bool
+}
+
+func (a *afterBody) Read(p []byte) (int, error) {
+	if !a.read && a.held.Len() > heldResultsLimit {
+		return 0, fmt.Errorf("%w: %d bytes", ErrHeldResultsLimited, a.held.Len())
+	}
+
+	n, err := a.body.Read(p)
+	if errors.Is(err, io.EOF) {
+		a.read = true
+	}
+
+	return n, err
+}
+
+func (a *afterBody) Write(p []byte) (int, error) {
+	if !a.read {
+		return a.held.Write(p)
+	}
+
+	if err := a.flush(); err != nil {
+		return 0, err
+	}
+
+	return a.w.Write(p)
+}
+
+// flush writes the held results.
+func (a *afterBody) flush() error {
+	if _, err := a.held.WriteTo(a.w); err != nil {
+		return fmt.Errorf("failed to write held results: %w", err)
+	}
+
+	return nil
+}
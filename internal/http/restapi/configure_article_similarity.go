@@ -12,6 +12,13 @@ import (
 	"github.com/devchallenge/article-similarity/internal/http/restapi/operations"
 )
 
+const (
+	// ndjsonMediaType is the media type of newline-delimited JSON streamed by the bulk import and the export.
+	ndjsonMediaType = "application/x-ndjson"
+	// csvMediaType is the media type of the CSV export.
+	csvMediaType = "text/csv"
+)
+
 //go:generate swagger generate server --target ../../internal --name ArticleSimilarityAPI --spec ../../api/spec.yaml --principal interface{} --exclude-main
 
 func configureFlags(api *operations.ArticleSimilarityAPI) {
@@ -25,6 +32,9 @@ func configureAPI(api *operations.ArticleSimilarityAPI) http.Handler {
 	api.UseRedoc()
 	api.JSONConsumer = runtime.JSONConsumer()
 	api.JSONProducer = runtime.JSONProducer()
+	api.RegisterConsumer(ndjsonMediaType, runtime.ByteStreamConsumer())
+	api.RegisterProducer(ndjsonMediaType, runtime.ByteStreamProducer())
+	api.RegisterProducer(csvMediaType, runtime.ByteStreamProducer())
 	api.PreServerShutdown = func() {}
 	api.ServerShutdown = func() {}
 
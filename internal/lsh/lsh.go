@@ -0,0 +1,121 @@
+package lsh
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	seed = 0x5eed5eed5eed5eed
+
+	uint64Bytes = 8
+)
+
+// LSH represents the locality-sensitive hashing over MinHash signatures.
+// For more information see https://en.wikipedia.org/wiki/MinHash#Locality_sensitive_hashing.
+//
+// The signature of bands*rows MinHash values is split into bands of rows values. Every band is hashed into a key.
+// Two sets share at least one key with probability 1-(1-s^rows)^bands where s is the Jaccard similarity of the sets.
+type LSH struct {
+	bands int
+	rows  int
+
+	seeds []uint64
+}
+
+// New returns a new LSH with the given number of bands and rows per band.
+// Hash functions are seeded deterministically, so keys are stable between runs and may be persisted.
+func New(bands, rows int) *LSH {
+	seeds := make([]uint64, bands*rows)
+
+	state := uint64(seed)
+	for i := range seeds {
+		state = splitMix64(state)
+		seeds[i] = state
+	}
+
+	return &LSH{
+		bands: bands,
+		rows:  rows,
+		seeds: seeds,
+	}
+}
+
+// Params returns the numbers of bands and rows. Keys of LSHs with different params do not match.
+func (l *LSH) Params() string {
+	return fmt.Sprintf("minhash bands=%d rows=%d", l.bands, l.rows)
+}
+
+// Probability returns the probability that sets of the Jaccard similarity share at least one key, so they are
+// compared as candidates.
+func (l *LSH) Probability(similarity float64) float64 {
+	return 1 - math.Pow(1-math.Pow(similarity, float64(l.rows)), float64(l.bands))
+}
+
+// Crossover returns the Jaccard similarity where the probability curve rises steepest, about (1/bands)^(1/rows).
+// Sets more similar are likely candidates, less similar ones are likely missed.
+func (l *LSH) Crossover() float64 {
+	return math.Pow(1/float64(l.bands), 1/float64(l.rows))
+}
+
+// Signature returns the MinHash signature of the set of words.
+// Every element of the signature is the minimum of the corresponding hash function over all words.
+func (l *LSH) Signature(words []string) []uint64 {
+	signature := make([]uint64, len(l.seeds))
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+
+	for _, word := range words {
+		h := hashWord(word)
+
+		for i, s := range l.seeds {
+			if v := splitMix64(h ^ s); v < signature[i] {
+				signature[i] = v
+			}
+		}
+	}
+
+	return signature
+}
+
+// Keys returns the band keys of the set of words. Sets sharing a key are candidates to be similar.
+func (l *LSH) Keys(words []string) []uint64 {
+	signature := l.Signature(words)
+	keys := make([]uint64, 0, l.bands)
+	buf := make([]byte, uint64Bytes)
+
+	for band := 0; band < l.bands; band++ {
+		h := fnv.New64a()
+
+		binary.LittleEndian.PutUint64(buf, uint64(band))
+		_, _ = h.Write(buf)
+
+		for _, v := range signature[band*l.rows : (band+1)*l.rows] {
+			binary.LittleEndian.PutUint64(buf, v)
+			_, _ = h.Write(buf)
+		}
+
+		keys = append(keys, h.Sum64())
+	}
+
+	return keys
+}
+
+func hashWord(word string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(word))
+
+	return h.Sum64()
+}
+
+// splitMix64 is a finalizer of the SplitMix64 generator. It is used as a family of hash functions.
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+
+	return x ^ (x >> 31)
+}
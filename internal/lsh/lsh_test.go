@@ -0,0 +1,90 @@
+package lsh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLSH_Signature(t *testing.T) {
+	l := New(4, 2)
+
+	res := l.Signature([]string{"hello", "world"})
+
+	assert.Len(t, res, 8)
+	assert.Equal(t, res, l.Signature([]string{"world", "hello", "world"}))
+}
+
+func TestLSH_Keys(t *testing.T) {
+	for name, tc := range map[string]struct {
+		wordsA    []string
+		wordsB    []string
+		shareKey  bool
+		equalKeys bool
+	}{
+		"when empty sets": {
+			wordsA:    []string{},
+			wordsB:    nil,
+			shareKey:  true,
+			equalKeys: true,
+		},
+		"when equal sets": {
+			wordsA:    []string{"hello", "beautiful", "world"},
+			wordsB:    []string{"world", "hello", "beautiful"},
+			shareKey:  true,
+			equalKeys: true,
+		},
+		"when similar sets": {
+			wordsA:    []string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"},
+			wordsB:    []string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "eleven"},
+			shareKey:  true,
+			equalKeys: false,
+		},
+		"when different sets": {
+			wordsA:    []string{"one", "two", "three", "four", "five"},
+			wordsB:    []string{"six", "seven", "eight", "nine", "ten"},
+			shareKey:  false,
+			equalKeys: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l := New(20, 5)
+
+			keysA := l.Keys(tc.wordsA)
+			keysB := l.Keys(tc.wordsB)
+
+			assert.Len(t, keysA, 20)
+			assert.Equal(t, tc.equalKeys, assert.ObjectsAreEqual(keysA, keysB))
+			assert.Equal(t, tc.shareKey, shareKey(keysA, keysB))
+		})
+	}
+}
+
+func TestLSH_Params(t *testing.T) {
+	assert.Equal(t, New(20, 5).Params(), New(20, 5).Params())
+	assert.NotEqual(t, New(20, 5).Params(), New(5, 20).Params())
+}
+
+func TestLSH_Probability(t *testing.T) {
+	l := New(20, 5)
+
+	assert.InDelta(t, 0.47, l.Probability(0.5), 0.01)
+	assert.InDelta(t, 0.99, l.Probability(0.8), 0.01)
+	assert.Equal(t, 1.0, l.Probability(1))
+	assert.Equal(t, 0.0, l.Probability(0))
+}
+
+func TestLSH_Crossover(t *testing.T) {
+	assert.InDelta(t, 0.55, New(20, 5).Crossover(), 0.01)
+	assert.InDelta(t, 0.5, New(16, 4).Crossover(), 0.01)
+}
+
+func shareKey(keysA, keysB []uint64) bool {
+	for i := range keysA {
+		if keysA[i] == keysB[i] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,980 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+)
+
+var ErrNoStaging = errors.New("no staging storage")
+
+// txKey marks the context of the running transaction with its storage.
+type txKey struct{}
+
+// Storage is a concurrency-safe storage keeping all data in memory. The data is lost when the process exits.
+// It is used for ephemeral runs, in tests and as the state of the file storage.
+type Storage struct {
+	// txMu serializes transactions with each other and with reads and changes made outside them, so changes of the
+	// running transaction are not read before it is committed.
+	txMu sync.RWMutex
+	mu   sync.RWMutex
+
+	data data
+	// undo rolls back changes of the running transaction in the reverse order.
+	undo []func()
+	// changes are sequences of the last creation, update or deletion of every article, changeSequence is the last
+	// one. They are kept only while the process runs, as they are read by the running reclustering. Changes rolled
+	// back are kept, so the articles are only restaged again.
+	changes        map[articlesim.ArticleID]int64
+	changeSequence int64
+	// staging keeps articles reclustered beside the stored ones, it is nil until reclustering starts.
+	staging *Storage
+}
+
+// data is the storage content. It is encoded as JSON by the file storage snapshot. ArticleKeys are LSHKeys of every
+// article, so keys of the article are removed without scanning all keys.
+type data struct {
+	Articles        map[articlesim.ArticleID]*article `json:"articles"`
+	DuplicateGroups []duplicateGroup                  `json:"duplicate_groups"`
+	ArticleCounter  int                               `json:"article_counter"`
+	GroupCounter    int                               `json:"duplicate_group_counter"`
+	LSHKeys         map[uint64][]articlesim.ArticleID `json:"lsh_keys"`
+	ArticleKeys     map[articlesim.ArticleID][]uint64 `json:"article_keys"`
+	IndexParams     string                            `json:"index_params"`
+
+	// ids are ids of articles in ascending order, so articles are listed in id order without sorting.
+	ids []articlesim.ArticleID
+}
+
+type article struct {
+	ID               articlesim.ArticleID        `json:"id"`
+	Content          string                      `json:"content"`
+	Title            string                      `json:"title"`
+	SourceURL        string                      `json:"source_url"`
+	Author           string                      `json:"author"`
+	PublishedAt      time.Time                   `json:"published_at"`
+	Tags             []string                    `json:"tags"`
+	Words            []string                    `json:"words"`
+	Language         string                      `json:"language"`
+	DuplicateIDs     []articlesim.ArticleID      `json:"duplicate_ids"`
+	Duplicates       []duplicate                 `json:"duplicates"`
+	IsUnique         bool                        `json:"is_unique"`
+	DuplicateGroupID articlesim.DuplicateGroupID `json:"duplicate_group_id"`
+}
+
+// duplicate is a link to a duplicate article with the similarity score. Articles of snapshots written before scores
+// were kept have duplicate ids without duplicates.
+type duplicate struct {
+	ID        articlesim.ArticleID `json:"id"`
+	Score     float64              `json:"score"`
+	Algorithm string               `json:"algorithm"`
+	Threshold float64              `json:"threshold"`
+}
+
+type duplicateGroup struct {
+	ID        articlesim.DuplicateGroupID `json:"id"`
+	ArticleID articlesim.ArticleID        `json:"article_id"`
+}
+
+func New() *Storage {
+	return &Storage{
+		txMu:    sync.RWMutex{},
+		mu:      sync.RWMutex{},
+		data:           newData(),
+		undo:           nil,
+		changes:        make(map[articlesim.ArticleID]int64),
+		changeSequence: 0,
+		staging:        nil,
+	}
+}
+
+func newData() data {
+	return data{
+		Articles:        make(map[articlesim.ArticleID]*article),
+		DuplicateGroups: make([]duplicateGroup, 0),
+		ArticleCounter:  0,
+		GroupCounter:    0,
+		LSHKeys:         make(map[uint64][]articlesim.ArticleID),
+		ArticleKeys:     make(map[articlesim.ArticleID][]uint64),
+		IndexParams:     "",
+		ids:             make([]articlesim.ArticleID, 0),
+	}
+}
+
+// WithTransaction runs fn serialized with other transactions. When fn fails, its changes are rolled back.
+func (s *Storage) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.undo = make([]func(), 0)
+
+	err := fn(context.WithValue(ctx, txKey{}, s))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		for i := len(s.undo) - 1; i >= 0; i-- {
+			s.undo[i]()
+		}
+	}
+
+	s.undo = nil
+
+	return err
+}
+
+func (s *Storage) NextArticleID(ctx context.Context) (articlesim.ArticleID, error) {
+	defer s.lock(ctx)()
+
+	counter := s.data.ArticleCounter
+	s.journal(ctx, func() { s.data.ArticleCounter = counter })
+
+	s.data.ArticleCounter++
+
+	return articlesim.ArticleID(s.data.ArticleCounter), nil
+}
+
+func (s *Storage) CreateArticle(ctx context.Context, id articlesim.ArticleID, content string,
+	metadata articlesim.Metadata, tokens articlesim.Tokens, duplicates []articlesim.Duplicate, isUnique bool,
+	duplicateGroupID articlesim.DuplicateGroupID) error {
+	defer s.lock(ctx)()
+
+	s.journalArticle(ctx, id)
+	s.changed(id)
+
+	s.putArticle(&article{
+		ID:               id,
+		Content:          content,
+		Title:            metadata.Title,
+		SourceURL:        metadata.SourceURL,
+		Author:           metadata.Author,
+		PublishedAt:      metadata.PublishedAt,
+		Tags:             copyStrings(metadata.Tags),
+		Words:            copyStrings(tokens.Words),
+		Language:         tokens.Language,
+		DuplicateIDs:     articlesim.DuplicateIDsOf(duplicates),
+		Duplicates:       fromModelDuplicates(duplicates),
+		IsUnique:         isUnique,
+		DuplicateGroupID: duplicateGroupID,
+	})
+
+	return nil
+}
+
+// CreateArticles stores the new articles with their index keys and memberships of their duplicate groups at once.
+func (s *Storage) CreateArticles(ctx context.Context, articles []articlesim.IndexedArticle) error {
+	defer s.lock(ctx)()
+
+	s.journalAppendedGroups(ctx)
+
+	for _, art := range articles {
+		s.journalArticle(ctx, art.ID)
+		s.changed(art.ID)
+
+		s.putArticle(&article{
+			ID:               art.ID,
+			Content:          art.Content,
+			Title:            art.Metadata.Title,
+			SourceURL:        art.Metadata.SourceURL,
+			Author:           art.Metadata.Author,
+			PublishedAt:      art.Metadata.PublishedAt,
+			Tags:             copyStrings(art.Metadata.Tags),
+			Words:            copyStrings(art.Tokens.Words),
+			Language:         art.Tokens.Language,
+			DuplicateIDs:     articlesim.DuplicateIDsOf(art.Duplicates),
+			Duplicates:       fromModelDuplicates(art.Duplicates),
+			IsUnique:         art.IsUnique,
+			DuplicateGroupID: art.DuplicateGroupID,
+		})
+
+		s.data.DuplicateGroups = append(s.data.DuplicateGroups, duplicateGroup{
+			ID:        art.DuplicateGroupID,
+			ArticleID: art.ID,
+		})
+
+		s.index(ctx, art.ID, art.Keys)
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateArticle(ctx context.Context, id articlesim.ArticleID,
+	duplicates []articlesim.Duplicate) error {
+	defer s.lock(ctx)()
+
+	art, ok := s.data.Articles[id]
+	if !ok {
+		return fmt.Errorf("failed to update article=%d: %w", id, articlesim.ErrArticleNotFound)
+	}
+
+	s.journalArticle(ctx, id)
+
+	art.DuplicateIDs = articlesim.DuplicateIDsOf(duplicates)
+	art.Duplicates = fromModelDuplicates(duplicates)
+
+	return nil
+}
+
+// RegroupArticle replaces duplicates of the article and moves it to the duplicate group.
+func (s *Storage) RegroupArticle(ctx context.Context, id articlesim.ArticleID, duplicates []articlesim.Duplicate,
+	isUnique bool, duplicateGroupID articlesim.DuplicateGroupID) error {
+	defer s.lock(ctx)()
+
+	art, ok := s.data.Articles[id]
+	if !ok {
+		return fmt.Errorf("failed to regroup article=%d: %w", id, articlesim.ErrArticleNotFound)
+	}
+
+	s.journalArticle(ctx, id)
+	s.journalGroups(ctx)
+
+	art.DuplicateIDs = articlesim.DuplicateIDsOf(duplicates)
+	art.Duplicates = fromModelDuplicates(duplicates)
+	art.IsUnique = isUnique
+	art.DuplicateGroupID = duplicateGroupID
+
+	for i, g := range s.data.DuplicateGroups {
+		if g.ArticleID == id {
+			s.data.DuplicateGroups[i].ID = duplicateGroupID
+		}
+	}
+
+	return nil
+}
+
+// DeleteArticle removes the article with its duplicate group membership and index keys.
+func (s *Storage) DeleteArticle(ctx context.Context, id articlesim.ArticleID) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.data.Articles[id]; !ok {
+		return fmt.Errorf("failed to delete article=%d: %w", id, articlesim.ErrArticleNotFound)
+	}
+
+	s.journalArticle(ctx, id)
+	s.journalGroups(ctx)
+	s.changed(id)
+
+	s.removeArticle(id)
+
+	groups := s.data.DuplicateGroups[:0]
+
+	for _, g := range s.data.DuplicateGroups {
+		if g.ArticleID != id {
+			groups = append(groups, g)
+		}
+	}
+
+	s.data.DuplicateGroups = groups
+
+	s.unindex(ctx, id)
+
+	return nil
+}
+
+func (s *Storage) ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	art, ok := s.data.Articles[id]
+	if !ok {
+		return articlesim.Article{}, fmt.Errorf("not found: %w", articlesim.ErrArticleNotFound)
+	}
+
+	return toModelArticle(art), nil
+}
+
+func (s *Storage) ForEachArticle(ctx context.Context, fn func(art articlesim.Article) error) error {
+	unlock := s.rlock(ctx)
+	articles := s.articles(func(art *article) bool { return true })
+	unlock()
+
+	for _, art := range articles {
+		if err := fn(art); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SnapshotArticles copies articles between transactions, so the snapshot has no changes of a running one.
+func (s *Storage) SnapshotArticles(ctx context.Context, fn func(art articlesim.Article) error) error {
+	unlock := s.rlock(ctx)
+	articles := s.articles(func(art *article) bool { return true })
+	unlock()
+
+	for _, art := range articles {
+		if err := fn(art); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UniqueArticles walks ids in ascending order from the first one after the given id until the page is filled.
+func (s *Storage) UniqueArticles(ctx context.Context, filter articlesim.ArticleFilter, after articlesim.ArticleID,
+	limit int) ([]articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	articles := make([]articlesim.Article, 0)
+
+	for _, id := range s.data.ids[s.searchID(after+1):] {
+		if len(articles) >= limit {
+			break
+		}
+
+		art := s.data.Articles[id]
+		if art.IsUnique && filter.Selects(toModelMetadata(art)) {
+			articles = append(articles, toModelArticle(art))
+		}
+	}
+
+	return articles, nil
+}
+
+func (s *Storage) ArticlesByDuplicateGroup(ctx context.Context,
+	duplicateGroupID articlesim.DuplicateGroupID) ([]articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	return s.articles(func(art *article) bool { return art.DuplicateGroupID == duplicateGroupID }), nil
+}
+
+func (s *Storage) NextDuplicateGroupID(ctx context.Context) (articlesim.DuplicateGroupID, error) {
+	defer s.lock(ctx)()
+
+	counter := s.data.GroupCounter
+	s.journal(ctx, func() { s.data.GroupCounter = counter })
+
+	s.data.GroupCounter++
+
+	return articlesim.DuplicateGroupID(s.data.GroupCounter), nil
+}
+
+func (s *Storage) CreateDuplicateGroup(ctx context.Context, id articlesim.DuplicateGroupID,
+	articleID articlesim.ArticleID) error {
+	defer s.lock(ctx)()
+
+	s.journalAppendedGroups(ctx)
+
+	s.data.DuplicateGroups = append(s.data.DuplicateGroups, duplicateGroup{
+		ID:        id,
+		ArticleID: articleID,
+	})
+
+	return nil
+}
+
+func (s *Storage) DuplicateGroups(ctx context.Context, after articlesim.DuplicateGroupID,
+	limit int) ([]articlesim.DuplicateGroupResp, error) {
+	defer s.rlock(ctx)()
+
+	ids := make(map[articlesim.DuplicateGroupID][]articlesim.ArticleID)
+
+	for _, g := range s.data.DuplicateGroups {
+		if g.ID > after {
+			ids[g.ID] = append(ids[g.ID], g.ArticleID)
+		}
+	}
+
+	groups := make([]articlesim.DuplicateGroupResp, 0, len(ids))
+
+	for gid, articleIDs := range ids {
+		if len(articleIDs) <= 1 {
+			continue
+		}
+
+		sort.Slice(articleIDs, func(i, j int) bool {
+			return articleIDs[i] < articleIDs[j]
+		})
+
+		groups = append(groups, articlesim.DuplicateGroupResp{
+			DuplicateGroupID: gid,
+			ArticleIDs:       articleIDs,
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].DuplicateGroupID < groups[j].DuplicateGroupID
+	})
+
+	if len(groups) > limit {
+		groups = groups[:limit]
+	}
+
+	return groups, nil
+}
+
+func (s *Storage) MergeDuplicateGroups(ctx context.Context, id articlesim.DuplicateGroupID,
+	mergedIDs []articlesim.DuplicateGroupID) error {
+	defer s.lock(ctx)()
+
+	s.journalGroups(ctx)
+
+	merged := make(map[articlesim.DuplicateGroupID]struct{}, len(mergedIDs))
+	for _, gid := range mergedIDs {
+		merged[gid] = struct{}{}
+	}
+
+	for i, g := range s.data.DuplicateGroups {
+		if _, ok := merged[g.ID]; ok {
+			s.data.DuplicateGroups[i].ID = id
+		}
+	}
+
+	for _, art := range s.data.Articles {
+		if _, ok := merged[art.DuplicateGroupID]; ok {
+			s.journalArticle(ctx, art.ID)
+
+			art.DuplicateGroupID = id
+			art.IsUnique = false
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) IndexArticle(ctx context.Context, id articlesim.ArticleID, keys []uint64) error {
+	defer s.lock(ctx)()
+
+	s.index(ctx, id, keys)
+
+	return nil
+}
+
+// ReindexArticle replaces tokens and index keys of the article.
+func (s *Storage) ReindexArticle(ctx context.Context, id articlesim.ArticleID, tokens articlesim.Tokens,
+	keys []uint64) error {
+	defer s.lock(ctx)()
+
+	art, ok := s.data.Articles[id]
+	if !ok {
+		return fmt.Errorf("failed to reindex article=%d: %w", id, articlesim.ErrArticleNotFound)
+	}
+
+	s.journalArticle(ctx, id)
+
+	art.Words = copyStrings(tokens.Words)
+	art.Language = tokens.Language
+
+	s.unindex(ctx, id)
+	s.index(ctx, id, keys)
+
+	return nil
+}
+
+func (s *Storage) IndexParams(ctx context.Context) (string, error) {
+	defer s.rlock(ctx)()
+
+	return s.data.IndexParams, nil
+}
+
+func (s *Storage) SetIndexParams(ctx context.Context, params string) error {
+	defer s.lock(ctx)()
+
+	old := s.data.IndexParams
+	s.journal(ctx, func() { s.data.IndexParams = old })
+
+	s.data.IndexParams = params
+
+	return nil
+}
+
+// index adds index keys of the article.
+func (s *Storage) index(ctx context.Context, id articlesim.ArticleID, keys []uint64) {
+	s.journalKeys(ctx, keys)
+	s.journalArticleKeys(ctx, id)
+
+	for _, k := range keys {
+		s.data.LSHKeys[k] = append(s.data.LSHKeys[k], id)
+	}
+
+	s.data.ArticleKeys[id] = append(copyKeys(s.data.ArticleKeys[id]), keys...)
+}
+
+// unindex removes index keys of the article.
+func (s *Storage) unindex(ctx context.Context, id articlesim.ArticleID) {
+	keys, ok := s.data.ArticleKeys[id]
+	if !ok {
+		return
+	}
+
+	s.journalArticleKeys(ctx, id)
+	delete(s.data.ArticleKeys, id)
+
+	for _, k := range keys {
+		ids, ok := s.data.LSHKeys[k]
+		if !ok || !containsID(ids, id) {
+			continue
+		}
+
+		s.journalKeys(ctx, []uint64{k})
+
+		kept := ids[:0]
+
+		for _, aid := range ids {
+			if aid != id {
+				kept = append(kept, aid)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(s.data.LSHKeys, k)
+		} else {
+			s.data.LSHKeys[k] = kept
+		}
+	}
+}
+
+// CandidateArticles looks up articles sharing the keys by their ids, so only the candidates are read and sorted.
+func (s *Storage) CandidateArticles(ctx context.Context, keys []uint64) ([]articlesim.Article, error) {
+	defer s.rlock(ctx)()
+
+	ids := make([]articlesim.ArticleID, 0)
+	seen := make(map[articlesim.ArticleID]struct{})
+
+	for _, k := range keys {
+		for _, id := range s.data.LSHKeys[k] {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	candidates := make([]articlesim.Article, 0, len(ids))
+
+	for _, id := range ids {
+		if art, ok := s.data.Articles[id]; ok {
+			candidates = append(candidates, toModelArticle(art))
+		}
+	}
+
+	return candidates, nil
+}
+
+// ChangeSequence returns the sequence of the last creation, update or deletion of an article.
+func (s *Storage) ChangeSequence(ctx context.Context) (int64, error) {
+	defer s.rlock(ctx)()
+
+	return s.changeSequence, nil
+}
+
+// ChangedArticleIDs returns ids of articles created, updated or deleted after the sequence ordered by id. Only
+// sequences of articles are compared, so articles are not read.
+func (s *Storage) ChangedArticleIDs(ctx context.Context, after int64) ([]articlesim.ArticleID, error) {
+	defer s.rlock(ctx)()
+
+	ids := make([]articlesim.ArticleID, 0)
+
+	for id, sequence := range s.changes {
+		if sequence > after {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return ids, nil
+}
+
+// Staging returns the storage where articles are reclustered. It keeps reclustered articles until they are swapped.
+func (s *Storage) Staging() *Storage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.staging == nil {
+		s.staging = New()
+	}
+
+	return s.staging
+}
+
+// SwapStaging blocks transactions and changes, runs catchUp and replaces the content with the staging one, then
+// empties the staging. catchUp reads the storage with the context of the blocking transaction. When it fails, the
+// content and the staging are kept.
+func (s *Storage) SwapStaging(ctx context.Context, catchUp func(ctx context.Context) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.RLock()
+	staging := s.staging
+	s.mu.RUnlock()
+
+	if staging == nil {
+		return ErrNoStaging
+	}
+
+	if err := catchUp(context.WithValue(ctx, txKey{}, s)); err != nil {
+		return err
+	}
+
+	s.Replace(staging)
+
+	s.mu.Lock()
+	s.staging = nil
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Replace replaces articles, duplicate groups, index keys and their params with the ones of the other storage.
+// The article counter is kept, so ids of deleted articles are not reused.
+func (s *Storage) Replace(other *Storage) {
+	other.mu.RLock()
+	d := other.data
+	other.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d.ArticleCounter < s.data.ArticleCounter {
+		d.ArticleCounter = s.data.ArticleCounter
+	}
+
+	d.ids = copyIDs(d.ids)
+	s.data = d
+}
+
+// MarshalJSON encodes the whole storage content.
+func (s *Storage) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	content, err := json.Marshal(s.data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	return content, nil
+}
+
+// UnmarshalJSON replaces the storage content with the decoded one.
+func (s *Storage) UnmarshalJSON(content []byte) error {
+	d := newData()
+	if err := json.Unmarshal(content, &d); err != nil {
+		return fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	for id := range d.Articles {
+		d.ids = append(d.ids, id)
+	}
+
+	// Snapshots written before article keys were kept have only the index keys.
+	if len(d.ArticleKeys) == 0 {
+		for k, ids := range d.LSHKeys {
+			for _, id := range ids {
+				d.ArticleKeys[id] = append(d.ArticleKeys[id], k)
+			}
+		}
+	}
+
+	sort.Slice(d.ids, func(i, j int) bool {
+		return d.ids[i] < d.ids[j]
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = d
+
+	return nil
+}
+
+// lock locks the storage for a change. A change made outside transactions waits for the running one, so it is not
+// lost when the transaction is rolled back.
+func (s *Storage) lock(ctx context.Context) func() {
+	if s.inTransaction(ctx) {
+		s.mu.Lock()
+
+		return s.mu.Unlock
+	}
+
+	s.txMu.Lock()
+	s.mu.Lock()
+
+	return func() {
+		s.mu.Unlock()
+		s.txMu.Unlock()
+	}
+}
+
+// rlock locks the storage for a read. A read made outside transactions waits for the running one, so it does not
+// return changes which are rolled back.
+func (s *Storage) rlock(ctx context.Context) func() {
+	if s.inTransaction(ctx) {
+		s.mu.RLock()
+
+		return s.mu.RUnlock
+	}
+
+	s.txMu.RLock()
+	s.mu.RLock()
+
+	return func() {
+		s.mu.RUnlock()
+		s.txMu.RUnlock()
+	}
+}
+
+func (s *Storage) inTransaction(ctx context.Context) bool {
+	tx, ok := ctx.Value(txKey{}).(*Storage)
+
+	return ok && tx == s
+}
+
+// changed records the creation, update or deletion of the article. It is called with the lock held.
+func (s *Storage) changed(id articlesim.ArticleID) {
+	s.changeSequence++
+	s.changes[id] = s.changeSequence
+}
+
+// journal records how to roll back the change made in the transaction. It is called with the lock held.
+func (s *Storage) journal(ctx context.Context, undo func()) {
+	if s.inTransaction(ctx) {
+		s.undo = append(s.undo, undo)
+	}
+}
+
+// journalArticle records the article, or its absence, before it is changed.
+func (s *Storage) journalArticle(ctx context.Context, id articlesim.ArticleID) {
+	if !s.inTransaction(ctx) {
+		return
+	}
+
+	old, ok := s.data.Articles[id]
+	if !ok {
+		s.journal(ctx, func() { s.removeArticle(id) })
+
+		return
+	}
+
+	// Changes replace slices of the article instead of modifying them, so a shallow copy is enough.
+	saved := *old
+	s.journal(ctx, func() { s.putArticle(&saved) })
+}
+
+// journalGroups records duplicate groups before they are changed in place.
+func (s *Storage) journalGroups(ctx context.Context) {
+	if !s.inTransaction(ctx) {
+		return
+	}
+
+	groups := make([]duplicateGroup, len(s.data.DuplicateGroups))
+	copy(groups, s.data.DuplicateGroups)
+	s.journal(ctx, func() { s.data.DuplicateGroups = groups })
+}
+
+// journalAppendedGroups records the number of duplicate groups before groups are appended, so they are truncated
+// on rollback without copying.
+func (s *Storage) journalAppendedGroups(ctx context.Context) {
+	n := len(s.data.DuplicateGroups)
+	s.journal(ctx, func() { s.data.DuplicateGroups = s.data.DuplicateGroups[:n] })
+}
+
+// journalKeys records article ids of the index keys before they are changed.
+func (s *Storage) journalKeys(ctx context.Context, keys []uint64) {
+	if !s.inTransaction(ctx) {
+		return
+	}
+
+	for _, k := range keys {
+		k := k
+
+		ids, ok := s.data.LSHKeys[k]
+		if !ok {
+			s.journal(ctx, func() { delete(s.data.LSHKeys, k) })
+
+			continue
+		}
+
+		saved := copyIDs(ids)
+		s.journal(ctx, func() { s.data.LSHKeys[k] = saved })
+	}
+}
+
+// journalArticleKeys records index keys of the article, or their absence, before they are changed.
+func (s *Storage) journalArticleKeys(ctx context.Context, id articlesim.ArticleID) {
+	if !s.inTransaction(ctx) {
+		return
+	}
+
+	keys, ok := s.data.ArticleKeys[id]
+	if !ok {
+		s.journal(ctx, func() { delete(s.data.ArticleKeys, id) })
+
+		return
+	}
+
+	saved := copyKeys(keys)
+	s.journal(ctx, func() { s.data.ArticleKeys[id] = saved })
+}
+
+// articles returns articles satisfying the filter ordered by id.
+func (s *Storage) articles(filter func(art *article) bool) []articlesim.Article {
+	articles := make([]articlesim.Article, 0, len(s.data.ids))
+
+	for _, id := range s.data.ids {
+		if art := s.data.Articles[id]; filter(art) {
+			articles = append(articles, toModelArticle(art))
+		}
+	}
+
+	return articles
+}
+
+// putArticle stores the article and adds its id to the ordered ids. Ids mostly grow, so they are appended.
+func (s *Storage) putArticle(art *article) {
+	if _, ok := s.data.Articles[art.ID]; !ok {
+		i := s.searchID(art.ID)
+		s.data.ids = append(s.data.ids, 0)
+		copy(s.data.ids[i+1:], s.data.ids[i:])
+		s.data.ids[i] = art.ID
+	}
+
+	s.data.Articles[art.ID] = art
+}
+
+// removeArticle removes the article and its id from the ordered ids.
+func (s *Storage) removeArticle(id articlesim.ArticleID) {
+	if _, ok := s.data.Articles[id]; !ok {
+		return
+	}
+
+	delete(s.data.Articles, id)
+
+	i := s.searchID(id)
+	s.data.ids = append(s.data.ids[:i], s.data.ids[i+1:]...)
+}
+
+// searchID returns the index of the first of the ordered ids which is not less than the id.
+func (s *Storage) searchID(id articlesim.ArticleID) int {
+	return sort.Search(len(s.data.ids), func(i int) bool {
+		return s.data.ids[i] >= id
+	})
+}
+
+func toModelArticle(art *article) articlesim.Article {
+	return articlesim.Article{
+		ID:       art.ID,
+		Content:  art.Content,
+		Metadata: toModelMetadata(art),
+		Tokens: articlesim.Tokens{
+			Words:    copyStrings(art.Words),
+			Language: art.Language,
+		},
+		DuplicateIDs:     copyIDs(art.DuplicateIDs),
+		Duplicates:       toModelDuplicates(art),
+		IsUnique:         art.IsUnique,
+		DuplicateGroupID: art.DuplicateGroupID,
+	}
+}
+
+func toModelMetadata(art *article) articlesim.Metadata {
+	return articlesim.Metadata{
+		Title:       art.Title,
+		SourceURL:   art.SourceURL,
+		Author:      art.Author,
+		PublishedAt: art.PublishedAt,
+		Tags:        copyStrings(art.Tags),
+	}
+}
+
+// toModelDuplicates returns links to duplicates of the article. Duplicate ids without links are read from snapshots
+// written before scores were kept.
+func toModelDuplicates(art *article) []articlesim.Duplicate {
+	if len(art.Duplicates) == 0 {
+		return articlesim.UnscoredDuplicates(art.DuplicateIDs)
+	}
+
+	duplicates := make([]articlesim.Duplicate, 0, len(art.Duplicates))
+	for _, d := range art.Duplicates {
+		duplicates = append(duplicates, articlesim.Duplicate{
+			ID:        d.ID,
+			Score:     d.Score,
+			Algorithm: d.Algorithm,
+			Threshold: d.Threshold,
+		})
+	}
+
+	return duplicates
+}
+
+func fromModelDuplicates(duplicates []articlesim.Duplicate) []duplicate {
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	res := make([]duplicate, 0, len(duplicates))
+	for _, d := range duplicates {
+		res = append(res, duplicate{
+			ID:        d.ID,
+			Score:     d.Score,
+			Algorithm: d.Algorithm,
+			Threshold: d.Threshold,
+		})
+	}
+
+	return res
+}
+
+func containsID(ids []articlesim.ArticleID, id articlesim.ArticleID) bool {
+	for _, aid := range ids {
+		if aid == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyIDs(ids []articlesim.ArticleID) []articlesim.ArticleID {
+	if ids == nil {
+		return nil
+	}
+
+	res := make([]articlesim.ArticleID, len(ids))
+	copy(res, ids)
+
+	return res
+}
+
+func copyKeys(keys []uint64) []uint64 {
+	if keys == nil {
+		return nil
+	}
+
+	res := make([]uint64, len(keys))
+	copy(res, keys)
+
+	return res
+}
+
+func copyStrings(words []string) []string {
+	if words == nil {
+		return nil
+	}
+
+	res := make([]string, len(words))
+	copy(res, words)
+
+	return res
+}
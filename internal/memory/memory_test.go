@@ -0,0 +1,274 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
+)
+
+func TestStorage_NextArticleID_Concurrent(t *testing.T) {
+	const goroutines = 50
+
+	s := New()
+	ids := make(chan articlesim.ArticleID, goroutines)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			id, err := s.NextArticleID(context.Background())
+			assert.NoError(t, err)
+
+			ids <- id
+		}()
+	}
+
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[articlesim.ArticleID]struct{}, goroutines)
+	for id := range ids {
+		seen[id] = struct{}{}
+	}
+
+	assert.Len(t, seen, goroutines)
+}
+
+func TestStorage_ArticleByID_ReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	duplicates := []articlesim.Duplicate{{ID: 2, Score: 1, Algorithm: "levenshtein", Threshold: 0.95}}
+	require.NoError(t, s.CreateArticle(ctx, 1, "a", articlesim.Metadata{}, articlesim.Tokens{}, duplicates, false, 1))
+
+	art, err := s.ArticleByID(ctx, 1)
+	require.NoError(t, err)
+
+	art.DuplicateIDs[0] = 3
+	art.Duplicates[0].Score = 0
+
+	art, err = s.ArticleByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{2}, art.DuplicateIDs)
+	assert.Equal(t, duplicates, art.Duplicates)
+}
+
+func TestStorage_WithTransaction_RollsBack(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	s := New()
+
+	id, err := s.NextArticleID(ctx)
+	require.NoError(t, err)
+	gid, err := s.NextDuplicateGroupID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateArticle(ctx, id, "a", articlesim.Metadata{}, articlesim.Tokens{Words: []string{"a"}},
+		nil, true, gid))
+	require.NoError(t, s.IndexArticle(ctx, id, []uint64{1, 2}))
+	require.NoError(t, s.CreateDuplicateGroup(ctx, gid, id))
+	require.NoError(t, s.SetIndexParams(ctx, "params"))
+
+	before, err := s.MarshalJSON()
+	require.NoError(t, err)
+
+	err = s.WithTransaction(ctx, func(ctx context.Context) error {
+		otherID, err := s.NextArticleID(ctx)
+		require.NoError(t, err)
+		otherGID, err := s.NextDuplicateGroupID(ctx)
+		require.NoError(t, err)
+		require.NoError(t, s.CreateArticle(ctx, otherID, "b", articlesim.Metadata{}, articlesim.Tokens{}, nil, true,
+			otherGID))
+		require.NoError(t, s.IndexArticle(ctx, otherID, []uint64{2, 3}))
+		require.NoError(t, s.CreateDuplicateGroup(ctx, otherGID, otherID))
+		require.NoError(t, s.MergeDuplicateGroups(ctx, otherGID, []articlesim.DuplicateGroupID{gid}))
+		require.NoError(t, s.ReindexArticle(ctx, id, articlesim.Tokens{Words: []string{"b"}}, []uint64{4}))
+		require.NoError(t, s.UpdateArticle(ctx, id, articlesim.UnscoredDuplicates([]articlesim.ArticleID{otherID})))
+		require.NoError(t, s.SetIndexParams(ctx, "other"))
+		require.NoError(t, s.DeleteArticle(ctx, id))
+
+		return errFailed
+	})
+	assert.True(t, errors.Is(err, errFailed))
+
+	after, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(after))
+
+	otherID, err := s.NextArticleID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(2), otherID)
+}
+
+func TestStorage_MarshalJSON(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	_, err := s.NextArticleID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateArticle(ctx, 1, "a", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+
+	content, err := s.MarshalJSON()
+	require.NoError(t, err)
+
+	restored := New()
+	require.NoError(t, restored.UnmarshalJSON(content))
+
+	art, err := restored.ArticleByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "a", art.Content)
+
+	id, err := restored.NextArticleID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, articlesim.ArticleID(2), id)
+}
+
+func TestStorage_UnmarshalJSON_UnscoredDuplicates(t *testing.T) {
+	// articles stored before scores were kept have only duplicate ids
+	content := `{"articles":{"2":{"id":2,"content":"a","duplicate_ids":[1],"is_unique":false,` +
+		`"duplicate_group_id":1}},"article_counter":2}`
+
+	s := New()
+	require.NoError(t, s.UnmarshalJSON([]byte(content)))
+
+	art, err := s.ArticleByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{1}, art.DuplicateIDs)
+	assert.Equal(t, []articlesim.Duplicate{{ID: 1, Score: 0, Algorithm: "", Threshold: 0}}, art.Duplicates)
+}
+
+func TestStorage_UniqueArticles_KeepsIDOrder(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	s := New()
+
+	for _, id := range []articlesim.ArticleID{3, 1, 4, 2} {
+		require.NoError(t, s.CreateArticle(ctx, id, "a", articlesim.Metadata{}, articlesim.Tokens{}, nil, id != 2,
+			articlesim.DuplicateGroupID(id)))
+	}
+
+	require.NoError(t, s.DeleteArticle(ctx, 3))
+
+	err := s.WithTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, s.CreateArticle(ctx, 5, "a", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 5))
+		require.NoError(t, s.DeleteArticle(ctx, 1))
+
+		return errFailed
+	})
+	assert.True(t, errors.Is(err, errFailed))
+
+	page, err := s.UniqueArticles(ctx, articlesim.ArticleFilter{}, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{1}, idsOf(page))
+
+	page, err = s.UniqueArticles(ctx, articlesim.ArticleFilter{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{4}, idsOf(page))
+}
+
+func TestStorage_CandidateArticles(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	for _, id := range []articlesim.ArticleID{3, 1, 2} {
+		require.NoError(t, s.CreateArticle(ctx, id, "a", articlesim.Metadata{}, articlesim.Tokens{}, nil, true,
+			articlesim.DuplicateGroupID(id)))
+		require.NoError(t, s.IndexArticle(ctx, id, []uint64{uint64(id), 10}))
+	}
+
+	candidates, err := s.CandidateArticles(ctx, []uint64{10, 3})
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{1, 2, 3}, idsOf(candidates))
+
+	candidates, err = s.CandidateArticles(ctx, []uint64{2, 4})
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{2}, idsOf(candidates))
+}
+
+func idsOf(articles []articlesim.Article) []articlesim.ArticleID {
+	ids := make([]articlesim.ArticleID, 0, len(articles))
+	for _, art := range articles {
+		ids = append(ids, art.ID)
+	}
+
+	return ids
+}
+
+func TestStorage_DeleteArticle_Unindexes(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		content string
+	}{
+		"when article keys are kept": {
+			content: `{"articles":{"1":{"id":1},"2":{"id":2}},"lsh_keys":{"1":[1,2],"2":[1]},` +
+				`"article_keys":{"1":[1,2],"2":[1]}}`,
+		},
+		"when snapshot without article keys": {
+			content: `{"articles":{"1":{"id":1},"2":{"id":2}},"lsh_keys":{"1":[1,2],"2":[1]}}`,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			s := New()
+			require.NoError(t, s.UnmarshalJSON([]byte(tc.content)))
+
+			require.NoError(t, s.DeleteArticle(ctx, 1))
+
+			candidates, err := s.CandidateArticles(ctx, []uint64{1, 2})
+			require.NoError(t, err)
+			assert.Equal(t, []articlesim.ArticleID{2}, idsOf(candidates))
+
+			require.NoError(t, s.ReindexArticle(ctx, 2, articlesim.Tokens{}, []uint64{3}))
+
+			candidates, err = s.CandidateArticles(ctx, []uint64{1, 2})
+			require.NoError(t, err)
+			assert.Empty(t, candidates)
+
+			content, err := s.MarshalJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"3":[2]}`, string(mustField(t, content, "lsh_keys")))
+			assert.JSONEq(t, `{"2":[3]}`, string(mustField(t, content, "article_keys")))
+		})
+	}
+}
+
+func mustField(t *testing.T, content []byte, name string) json.RawMessage {
+	t.Helper()
+
+	fields := make(map[string]json.RawMessage)
+	require.NoError(t, json.Unmarshal(content, &fields))
+
+	return fields[name]
+}
+
+func TestStorage_ChangedArticleIDs(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	require.NoError(t, s.CreateArticle(ctx, 1, "a", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 1))
+	require.NoError(t, s.CreateArticle(ctx, 2, "b", articlesim.Metadata{}, articlesim.Tokens{}, nil, true, 2))
+
+	since, err := s.ChangeSequence(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateArticles(ctx, []articlesim.IndexedArticle{{
+		Article: articlesim.Article{ID: 4, Content: "d", IsUnique: true, DuplicateGroupID: 4},
+		Keys:    nil,
+	}}))
+	require.NoError(t, s.DeleteArticle(ctx, 1))
+	require.NoError(t, s.UpdateArticle(ctx, 2, nil))
+
+	ids, err := s.ChangedArticleIDs(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, []articlesim.ArticleID{1, 4}, ids)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,52 +17,261 @@ import (
 )
 
 const (
-	maxArticles        = 1000
-	maxDuplicateGroups = 1000
-
 	collectionArticles        = "articles"
 	collectionDuplicateGroups = "duplicate_groups"
 	collectionAutoincrement   = "autoincrement"
+	collectionLSHKeys         = "lsh_keys"
+	collectionArticleChanges  = "article_changes"
+
+	// counterTransactions is the autoincrement counter updated by every transaction.
+	counterTransactions = "transactions"
+
+	// stagingSuffix is appended to names of collections where articles are reclustered.
+	stagingSuffix = "_staging"
+
+	// indexParamsID is the id of the document keeping params of the index in the lsh keys collection, so the params
+	// are swapped with the keys.
+	indexParamsID = "index_params"
+
+	// swapBlocked marks the transactions counter while the staging catches up with stored articles, swapRenaming
+	// while the staging collections are renamed. Transactions wait while the counter is marked.
+	swapBlocked  = "blocked"
+	swapRenaming = "renaming"
+
+	// swapRetryInterval is the interval between attempts of the transaction waiting for the swap.
+	swapRetryInterval = 100 * time.Millisecond
+
+	// swapLease is the time the mark of the swap is kept without renewal. The swap of the process which stopped
+	// renewing the mark is taken over when the lease expires. The mark is renewed every swapRenewInterval.
+	swapLease         = 30 * time.Second
+	swapRenewInterval = swapLease / 3
+
+	// exportPageSize is the number of articles read at once by SnapshotArticles.
+	exportPageSize = 1000
+)
+
+var (
+	ErrSwapping           = errors.New("staging is being swapped")
+	ErrSwapLeaseLost      = errors.New("staging swap lease is lost")
+	ErrReplicaSetRequired = errors.New("replica set required: mongodb supports transactions only on a replica set " +
+		"or a sharded cluster, run it with --replSet and initiate the set")
 )
 
+// article keeps the metadata fields only when they are set. Source is the outlet of the source URL, so articles
+// are filtered by it with the index.
 type article struct {
 	ID               articlesim.ArticleID        `bson:"id"`
 	Content          string                      `bson:"content"`
+	Title            string                      `bson:"title,omitempty"`
+	SourceURL        string                      `bson:"source_url,omitempty"`
+	Source           string                      `bson:"source,omitempty"`
+	Author           string                      `bson:"author,omitempty"`
+	PublishedAt      time.Time                   `bson:"published_at,omitempty"`
+	Tags             []string                    `bson:"tags,omitempty"`
+	Words            []string                    `bson:"words"`
+	Language         string                      `bson:"language"`
 	DuplicateIDs     []articlesim.ArticleID      `bson:"duplicate_ids"`
+	Duplicates       []duplicate                 `bson:"duplicates"`
 	IsUnique         bool                        `bson:"is_unique"`
 	DuplicateGroupID articlesim.DuplicateGroupID `bson:"duplicate_group_id"`
 }
 
+// duplicate is a link to a duplicate article with the similarity score. Articles stored before scores were kept
+// have duplicate ids without duplicates.
+type duplicate struct {
+	ID        articlesim.ArticleID `bson:"id"`
+	Score     float64              `bson:"score"`
+	Algorithm string               `bson:"algorithm"`
+	Threshold float64              `bson:"threshold"`
+}
+
 type duplicateGroup struct {
 	ID        articlesim.DuplicateGroupID `bson:"id"`
 	ArticleID articlesim.ArticleID        `bson:"article_id"`
 }
 
+type groupedDuplicateGroup struct {
+	ID         articlesim.DuplicateGroupID `bson:"_id"`
+	ArticleIDs []articlesim.ArticleID      `bson:"article_ids"`
+}
+
+type lshKey struct {
+	Key       int64                `bson:"key"`
+	ArticleID articlesim.ArticleID `bson:"article_id"`
+}
+
+// articleChange is the sequence of the last creation, update or deletion of the article. Sequences are counted by
+// the autoincrement counter named by the collection.
+type articleChange struct {
+	ArticleID articlesim.ArticleID `bson:"article_id"`
+	Sequence  int64                `bson:"sequence"`
+}
+
+type indexParams struct {
+	ID     string `bson:"_id"`
+	Params string `bson:"params"`
+}
+
+// autoincrement is the counter. Swap marks the transactions counter while the staging is swapped by the owner
+// storage, the lease of the owner expires at SwapExpires of the server clock.
 type autoincrement struct {
-	ID         primitive.ObjectID `bson:"_id"`
-	Collection string             `bson:"collection"`
-	Counter    int                `bson:"counter"`
-	UpdatedAt  time.Time          `bson:"updated_at"`
+	ID          primitive.ObjectID `bson:"_id"`
+	Collection  string             `bson:"collection"`
+	Counter     int                `bson:"counter"`
+	Swap        string             `bson:"swap,omitempty"`
+	SwapOwner   string             `bson:"swap_owner,omitempty"`
+	SwapExpires time.Time          `bson:"swap_expires,omitempty"`
+	UpdatedAt   time.Time          `bson:"updated_at"`
 }
 
 type Storage struct {
+	client                   *mongo.Client
+	database                 *mongo.Database
 	collectionArticle        *mongo.Collection
 	collectionDuplicateGroup *mongo.Collection
 	collectionAutoincrement  *mongo.Collection
+	collectionLSHKey         *mongo.Collection
+	// collectionArticleChange keeps changes of articles, it is nil for the staging, as its changes are not read.
+	collectionArticleChange *mongo.Collection
+	// counterTransactions is the name of the counter updated by transactions of the storage.
+	counterTransactions string
+	// owner identifies the storage in the mark of the swap it runs.
+	owner string
 }
 
 func New(mc *mongo.Client, database string) *Storage {
 	db := mc.Database(database)
 
 	return &Storage{
+		client:                   mc,
+		database:                 db,
 		collectionArticle:        db.Collection(collectionArticles),
 		collectionDuplicateGroup: db.Collection(collectionDuplicateGroups),
 		collectionAutoincrement:  db.Collection(collectionAutoincrement),
+		collectionLSHKey:         db.Collection(collectionLSHKeys),
+		collectionArticleChange:  db.Collection(collectionArticleChanges),
+		counterTransactions:      counterTransactions,
+		owner:                    primitive.NewObjectID().Hex(),
 	}
 }
 
+// serverStatus is the part of the isMaster command response telling whether the server supports transactions.
+// Replica set members report the set name, routers of sharded clusters the isdbgrid message.
+type serverStatus struct {
+	SetName string `bson:"setName"`
+	Msg     string `bson:"msg"`
+}
+
+// EnsureTransactions checks that the server supports multi-document transactions, so a standalone server fails at
+// start instead of on every change of articles.
+func (s *Storage) EnsureTransactions(ctx context.Context) error {
+	res := s.client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}})
+
+	status := serverStatus{}
+	if err := res.Decode(&status); err != nil {
+		return fmt.Errorf("failed to get server status: %w", err)
+	}
+
+	if status.SetName == "" && status.Msg != "isdbgrid" {
+		return ErrReplicaSetRequired
+	}
+
+	return nil
+}
+
+// EnsureIndexes creates indexes required by storage queries if they do not exist.
+func (s *Storage) EnsureIndexes(ctx context.Context) error {
+	for _, index := range []struct {
+		collection *mongo.Collection
+		keys       bson.D
+		unique     bool
+	}{
+		{collection: s.collectionLSHKey, keys: bson.D{{Key: "key", Value: 1}}},
+		{collection: s.collectionArticle, keys: bson.D{{Key: "id", Value: 1}}},
+		{collection: s.collectionArticle, keys: bson.D{{Key: "is_unique", Value: 1}, {Key: "id", Value: 1}}},
+		{collection: s.collectionArticle, keys: bson.D{
+			{Key: "is_unique", Value: 1}, {Key: "source", Value: 1}, {Key: "id", Value: 1},
+		}},
+		{collection: s.collectionArticle, keys: bson.D{
+			{Key: "is_unique", Value: 1}, {Key: "tags", Value: 1}, {Key: "id", Value: 1},
+		}},
+		{collection: s.collectionArticle, keys: bson.D{
+			{Key: "is_unique", Value: 1}, {Key: "published_at", Value: 1}, {Key: "id", Value: 1},
+		}},
+		{collection: s.collectionDuplicateGroup, keys: bson.D{{Key: "id", Value: 1}, {Key: "article_id", Value: 1}}},
+		{collection: s.collectionDuplicateGroup, keys: bson.D{{Key: "article_id", Value: 1}}},
+		{collection: s.collectionArticle, keys: bson.D{{Key: "duplicate_group_id", Value: 1}, {Key: "id", Value: 1}}},
+		{collection: s.collectionLSHKey, keys: bson.D{{Key: "article_id", Value: 1}}},
+		{collection: s.collectionAutoincrement, keys: bson.D{{Key: "collection", Value: 1}}, unique: true},
+		{collection: s.collectionArticleChange, keys: bson.D{{Key: "article_id", Value: 1}}, unique: true},
+		{collection: s.collectionArticleChange, keys: bson.D{{Key: "sequence", Value: 1}}},
+	} {
+		// The staging has no changes collection.
+		if index.collection == nil {
+			continue
+		}
+
+		if _, err := index.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    index.keys,
+			Options: options.Index().SetUnique(index.unique),
+		}); err != nil {
+			return fmt.Errorf("failed to create %s index: %w", index.collection.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// WithTransaction runs fn in the multi-document transaction, so MongoDB must run as a replica set.
+// Every transaction updates the same counter document first. Concurrent transactions conflict on it and the driver
+// retries them one after another, so they never decide on duplicates from the same snapshot. While the staging
+// is swapped, the counter is marked and the transaction waits for the swap. The swap whose lease expired is resumed
+// by the waiting transaction, so changes are not blocked by the stopped process.
+func (s *Storage) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		err := s.withTransaction(ctx, fn)
+		if !errors.Is(err, ErrSwapping) {
+			return err
+		}
+
+		if err := s.ResumeSwap(ctx); err != nil {
+			return fmt.Errorf("failed to resume expired swap: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for swap: %w", ctx.Err())
+		case <-time.After(swapRetryInterval):
+		}
+	}
+}
+
+func (s *Storage) withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := s.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		inc, err := s.autoincrement(sessCtx, s.counterTransactions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get autoicrement for transactions: %w", err)
+		}
+
+		if inc.Swap != "" {
+			return nil, ErrSwapping
+		}
+
+		return nil, fn(sessCtx)
+	})
+
+	return err
+}
+
 func (s *Storage) NextArticleID(ctx context.Context) (articlesim.ArticleID, error) {
-	inc, err := s.autoincrement(ctx, collectionArticles)
+	inc, err := s.autoincrement(ctx, s.collectionArticle.Name())
 	if err != nil {
 		return 0, fmt.Errorf("failed to get autoicrement for articles: %w", err)
 	}
@@ -69,11 +280,21 @@ func (s *Storage) NextArticleID(ctx context.Context) (articlesim.ArticleID, erro
 }
 
 func (s *Storage) CreateArticle(ctx context.Context, id articlesim.ArticleID, content string,
-	duplicateIDs []articlesim.ArticleID, isUnique bool, duplicateGroupID articlesim.DuplicateGroupID) error {
+	metadata articlesim.Metadata, tokens articlesim.Tokens, duplicates []articlesim.Duplicate, isUnique bool,
+	duplicateGroupID articlesim.DuplicateGroupID) error {
 	art := article{
 		ID:               id,
 		Content:          content,
-		DuplicateIDs:     duplicateIDs,
+		Title:            metadata.Title,
+		SourceURL:        metadata.SourceURL,
+		Source:           metadata.Source(),
+		Author:           metadata.Author,
+		PublishedAt:      metadata.PublishedAt,
+		Tags:             metadata.Tags,
+		Words:            tokens.Words,
+		Language:         tokens.Language,
+		DuplicateIDs:     articlesim.DuplicateIDsOf(duplicates),
+		Duplicates:       fromModelDuplicates(duplicates),
 		IsUnique:         isUnique,
 		DuplicateGroupID: duplicateGroupID,
 	}
@@ -87,14 +308,82 @@ func (s *Storage) CreateArticle(ctx context.Context, id articlesim.ArticleID, co
 		return fmt.Errorf("failed to insert article: %w", err)
 	}
 
-	return nil
+	return s.changed(ctx, id)
+}
+
+// CreateArticles inserts the articles, their lsh keys and duplicate group documents with a bulk write
+// per collection.
+func (s *Storage) CreateArticles(ctx context.Context, articles []articlesim.IndexedArticle) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	arts := make([]mongo.WriteModel, 0, len(articles))
+	groups := make([]mongo.WriteModel, 0, len(articles))
+	keys := make([]mongo.WriteModel, 0, len(articles))
+
+	for _, art := range articles {
+		arts = append(arts, mongo.NewInsertOneModel().SetDocument(article{
+			ID:               art.ID,
+			Content:          art.Content,
+			Title:            art.Metadata.Title,
+			SourceURL:        art.Metadata.SourceURL,
+			Source:           art.Metadata.Source(),
+			Author:           art.Metadata.Author,
+			PublishedAt:      art.Metadata.PublishedAt,
+			Tags:             art.Metadata.Tags,
+			Words:            art.Tokens.Words,
+			Language:         art.Tokens.Language,
+			DuplicateIDs:     articlesim.DuplicateIDsOf(art.Duplicates),
+			Duplicates:       fromModelDuplicates(art.Duplicates),
+			IsUnique:         art.IsUnique,
+			DuplicateGroupID: art.DuplicateGroupID,
+		}))
+		groups = append(groups, mongo.NewInsertOneModel().SetDocument(duplicateGroup{
+			ID:        art.DuplicateGroupID,
+			ArticleID: art.ID,
+		}))
+
+		for _, k := range art.Keys {
+			keys = append(keys, mongo.NewInsertOneModel().SetDocument(lshKey{
+				Key:       int64(k),
+				ArticleID: art.ID,
+			}))
+		}
+	}
+
+	if _, err := s.collectionArticle.BulkWrite(ctx, arts); err != nil {
+		return fmt.Errorf("failed to insert articles: %w", err)
+	}
+
+	if _, err := s.collectionDuplicateGroup.BulkWrite(ctx, groups); err != nil {
+		return fmt.Errorf("failed to insert duplicate groups: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, err := s.collectionLSHKey.BulkWrite(ctx, keys); err != nil {
+		return fmt.Errorf("failed to insert lsh keys: %w", err)
+	}
+
+	ids := make([]articlesim.ArticleID, 0, len(articles))
+	for _, art := range articles {
+		ids = append(ids, art.ID)
+	}
+
+	return s.changed(ctx, ids...)
 }
 
 func (s *Storage) UpdateArticle(ctx context.Context, id articlesim.ArticleID,
-	duplicateIDs []articlesim.ArticleID) error {
+	duplicates []articlesim.Duplicate) error {
 	filter := bson.D{{Key: "id", Value: id}}
 	update := bson.M{
-		"$set": bson.M{"duplicate_ids": duplicateIDs},
+		"$set": bson.M{
+			"duplicate_ids": articlesim.DuplicateIDsOf(duplicates),
+			"duplicates":    fromModelDuplicates(duplicates),
+		},
 	}
 
 	if err := s.collectionArticle.FindOneAndUpdate(ctx, filter, update, nil).Err(); err != nil {
@@ -104,6 +393,132 @@ func (s *Storage) UpdateArticle(ctx context.Context, id articlesim.ArticleID,
 	return nil
 }
 
+// RegroupArticle replaces duplicates of the article and moves it with its duplicate group document to the group.
+func (s *Storage) RegroupArticle(ctx context.Context, id articlesim.ArticleID, duplicates []articlesim.Duplicate,
+	isUnique bool, duplicateGroupID articlesim.DuplicateGroupID) error {
+	filter := bson.D{{Key: "id", Value: id}}
+	update := bson.M{
+		"$set": bson.M{
+			"duplicate_ids":      articlesim.DuplicateIDsOf(duplicates),
+			"duplicates":         fromModelDuplicates(duplicates),
+			"is_unique":          isUnique,
+			"duplicate_group_id": duplicateGroupID,
+		},
+	}
+
+	res, err := s.collectionArticle.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update article: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("failed to update article=%d: %w", id, articlesim.ErrArticleNotFound)
+	}
+
+	filter = bson.D{{Key: "article_id", Value: id}}
+	update = bson.M{
+		"$set": bson.M{"id": duplicateGroupID},
+	}
+
+	if _, err := s.collectionDuplicateGroup.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to update duplicate group: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteArticle removes the article with its duplicate group document and lsh keys.
+func (s *Storage) DeleteArticle(ctx context.Context, id articlesim.ArticleID) error {
+	res, err := s.collectionArticle.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	if err != nil {
+		return fmt.Errorf("failed to delete article: %w", err)
+	}
+
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("failed to delete article=%d: %w", id, articlesim.ErrArticleNotFound)
+	}
+
+	filter := bson.D{{Key: "article_id", Value: id}}
+
+	if _, err := s.collectionDuplicateGroup.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete duplicate group: %w", err)
+	}
+
+	if _, err := s.collectionLSHKey.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete lsh keys: %w", err)
+	}
+
+	return s.changed(ctx, id)
+}
+
+// ChangeSequence returns the sequence of the last creation, update or deletion of an article.
+func (s *Storage) ChangeSequence(ctx context.Context) (int64, error) {
+	res := s.collectionAutoincrement.FindOne(ctx, bson.M{"collection": collectionArticleChanges})
+
+	inc := autoincrement{}
+	if err := res.Decode(&inc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("failed to find article changes counter: %w", err)
+	}
+
+	return int64(inc.Counter), nil
+}
+
+// ChangedArticleIDs returns ids of articles created, updated or deleted after the sequence ordered by id. Changes
+// are found by the sequence index, so only changed articles are read.
+func (s *Storage) ChangedArticleIDs(ctx context.Context, after int64) ([]articlesim.ArticleID, error) {
+	cur, err := s.collectionArticleChange.Find(ctx, bson.M{"sequence": bson.M{"$gt": after}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find article changes: %w", err)
+	}
+
+	changes := make([]articleChange, 0)
+	if err := cur.All(ctx, &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode article changes: %w", err)
+	}
+
+	ids := make([]articlesim.ArticleID, 0, len(changes))
+	for _, c := range changes {
+		ids = append(ids, c.ArticleID)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return ids, nil
+}
+
+// changed records the creation, update or deletion of the articles with the next sequence. Changes of the staging
+// are not recorded.
+func (s *Storage) changed(ctx context.Context, ids ...articlesim.ArticleID) error {
+	if s.collectionArticleChange == nil {
+		return nil
+	}
+
+	inc, err := s.autoincrement(ctx, collectionArticleChanges)
+	if err != nil {
+		return fmt.Errorf("failed to get autoincrement for article changes: %w", err)
+	}
+
+	models := make([]mongo.WriteModel, 0, len(ids))
+	for _, id := range ids {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"article_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"sequence": int64(inc.Counter)}}).
+			SetUpsert(true))
+	}
+
+	if _, err := s.collectionArticleChange.BulkWrite(ctx, models); err != nil {
+		return fmt.Errorf("failed to record article changes: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Storage) ArticleByID(ctx context.Context, id articlesim.ArticleID) (articlesim.Article, error) {
 	res := s.collectionArticle.FindOne(ctx, bson.D{{Key: "id", Value: id}})
 	if errors.Is(res.Err(), mongo.ErrNoDocuments) {
@@ -122,36 +537,135 @@ func (s *Storage) ArticleByID(ctx context.Context, id articlesim.ArticleID) (art
 	return toModelArticle(art), nil
 }
 
-func (s *Storage) AllArticles(ctx context.Context) ([]articlesim.Article, error) {
-	return s.articles(ctx, bson.D{})
-}
-
-func (s *Storage) UniqueArticles(ctx context.Context) ([]articlesim.Article, error) {
-	return s.articles(ctx, bson.D{{Key: "is_unique", Value: true}})
-}
-
-func (s *Storage) articles(ctx context.Context, filter bson.D) ([]articlesim.Article, error) {
-	articles := make([]articlesim.Article, 0, maxArticles)
+// ForEachArticle streams articles with the cursor, so they are not loaded in memory at once.
+func (s *Storage) ForEachArticle(ctx context.Context, fn func(art articlesim.Article) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
 
-	cur, err := s.collectionArticle.Find(ctx, filter)
+	cur, err := s.collectionArticle.Find(ctx, bson.D{}, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find articles: %w", err)
+		return fmt.Errorf("failed to find articles: %w", err)
 	}
 
-	for cur.TryNext(ctx) && len(articles) != maxArticles {
+	defer func() {
+		if err := cur.Close(ctx); err != nil {
+			log.Printf("failed to close cursor: %v", err)
+		}
+	}()
+
+	for cur.Next(ctx) {
 		art := article{}
 		if err := cur.Decode(&art); err != nil {
-			return nil, fmt.Errorf("failed to cursor decode to article: %w", err)
+			return fmt.Errorf("failed to cursor decode to article: %w", err)
 		}
 
-		articles = append(articles, toModelArticle(art))
+		if err := fn(toModelArticle(art)); err != nil {
+			return err
+		}
 	}
 
-	return articles, nil
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("failed to iterate articles: %w", err)
+	}
+
+	return nil
+}
+
+// SnapshotArticles iterates articles by pages of exportPageSize ordered by id up to the last article found when
+// the iteration starts, so articles created during the iteration are not seen. Every page is a short read, so no
+// transaction or cursor is kept open while fn is called and large collections are iterated within server limits.
+func (s *Storage) SnapshotArticles(ctx context.Context, fn func(art articlesim.Article) error) error {
+	last, err := s.lastArticleID(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetLimit(exportPageSize)
+
+	for after := articlesim.ArticleID(0); after < last; {
+		filter := bson.D{{Key: "id", Value: bson.D{{Key: "$gt", Value: after}, {Key: "$lte", Value: last}}}}
+
+		page, err := s.find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+
+		if len(page) == 0 {
+			return nil
+		}
+
+		for _, art := range page {
+			if err := fn(art); err != nil {
+				return err
+			}
+		}
+
+		after = page[len(page)-1].ID
+	}
+
+	return nil
+}
+
+// lastArticleID returns the greatest id of stored articles or 0 when there are no articles.
+func (s *Storage) lastArticleID(ctx context.Context) (articlesim.ArticleID, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}}).SetProjection(bson.M{"id": 1})
+
+	art := article{}
+	if err := s.collectionArticle.FindOne(ctx, bson.D{}, opts).Decode(&art); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("failed to find last article: %w", err)
+	}
+
+	return art.ID, nil
+}
+
+// UniqueArticles finds the page with the index on the unique flag and the field of the filter.
+func (s *Storage) UniqueArticles(ctx context.Context, articleFilter articlesim.ArticleFilter,
+	after articlesim.ArticleID, limit int) ([]articlesim.Article, error) {
+	filter := bson.D{
+		{Key: "is_unique", Value: true},
+		{Key: "id", Value: bson.D{{Key: "$gt", Value: after}}},
+	}
+
+	if articleFilter.Source != "" {
+		filter = append(filter, bson.E{Key: "source", Value: articleFilter.Source})
+	}
+
+	if articleFilter.Tag != "" {
+		filter = append(filter, bson.E{Key: "tags", Value: articleFilter.Tag})
+	}
+
+	published := bson.D{}
+
+	if !articleFilter.PublishedFrom.IsZero() {
+		published = append(published, bson.E{Key: "$gte", Value: articleFilter.PublishedFrom})
+	}
+
+	if !articleFilter.PublishedTo.IsZero() {
+		published = append(published, bson.E{Key: "$lte", Value: articleFilter.PublishedTo})
+	}
+
+	if len(published) != 0 {
+		filter = append(filter, bson.E{Key: "published_at", Value: published})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetLimit(int64(limit))
+
+	return s.find(ctx, filter, opts)
+}
+
+func (s *Storage) ArticlesByDuplicateGroup(ctx context.Context,
+	duplicateGroupID articlesim.DuplicateGroupID) ([]articlesim.Article, error) {
+	filter := bson.D{{Key: "duplicate_group_id", Value: duplicateGroupID}}
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+
+	return s.find(ctx, filter, opts)
 }
 
 func (s *Storage) NextDuplicateGroupID(ctx context.Context) (articlesim.DuplicateGroupID, error) {
-	inc, err := s.autoincrement(ctx, collectionDuplicateGroups)
+	inc, err := s.autoincrement(ctx, s.collectionDuplicateGroup.Name())
 	if err != nil {
 		return 0, fmt.Errorf("failed to get autoicrement for duplicate groups: %w", err)
 	}
@@ -178,39 +692,575 @@ func (s *Storage) CreateDuplicateGroup(ctx context.Context, id articlesim.Duplic
 	return nil
 }
 
-func (s *Storage) AllDuplicateGroups(ctx context.Context) ([]articlesim.DuplicateGroup, error) {
-	groups := make([]articlesim.DuplicateGroup, 0, maxDuplicateGroups)
+// MergeDuplicateGroups moves articles of the merged groups to the duplicate group. The duplicate group keeps
+// its unique article, so articles of the merged groups are not unique anymore.
+func (s *Storage) MergeDuplicateGroups(ctx context.Context, id articlesim.DuplicateGroupID,
+	mergedIDs []articlesim.DuplicateGroupID) error {
+	filter := bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: mergedIDs}}}}
+	update := bson.M{
+		"$set": bson.M{"id": id},
+	}
+
+	if _, err := s.collectionDuplicateGroup.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to update duplicate groups: %w", err)
+	}
+
+	filter = bson.D{{Key: "duplicate_group_id", Value: bson.D{{Key: "$in", Value: mergedIDs}}}}
+	update = bson.M{
+		"$set": bson.M{"duplicate_group_id": id, "is_unique": false},
+	}
+
+	if _, err := s.collectionArticle.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to update articles duplicate group: %w", err)
+	}
+
+	return nil
+}
+
+// DuplicateGroups groups duplicate group documents by id and skips groups with the only article.
+func (s *Storage) DuplicateGroups(ctx context.Context, after articlesim.DuplicateGroupID,
+	limit int) ([]articlesim.DuplicateGroupResp, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "id", Value: bson.D{{Key: "$gt", Value: after}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "id", Value: 1}, {Key: "article_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$id"},
+			{Key: "article_ids", Value: bson.D{{Key: "$push", Value: "$article_id"}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "article_ids.1", Value: bson.D{{Key: "$exists", Value: true}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
 
-	cur, err := s.collectionDuplicateGroup.Find(ctx, bson.D{})
+	cur, err := s.collectionDuplicateGroup.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
 	if err != nil {
-		return nil, fmt.Errorf("failed to find duplicate groups: %w", err)
+		return nil, fmt.Errorf("failed to aggregate duplicate groups: %w", err)
 	}
 
-	for cur.TryNext(ctx) && len(groups) != maxDuplicateGroups {
-		group := duplicateGroup{}
+	defer func() {
+		if err := cur.Close(ctx); err != nil {
+			log.Printf("failed to close cursor: %v", err)
+		}
+	}()
+
+	groups := make([]articlesim.DuplicateGroupResp, 0, limit)
+
+	for cur.Next(ctx) {
+		group := groupedDuplicateGroup{}
 		if err := cur.Decode(&group); err != nil {
 			return nil, fmt.Errorf("failed to cursor decode to group: %w", err)
 		}
 
-		groups = append(groups, articlesim.DuplicateGroup{
+		groups = append(groups, articlesim.DuplicateGroupResp{
 			DuplicateGroupID: group.ID,
-			ArticleID:        group.ArticleID,
+			ArticleIDs:       group.ArticleIDs,
 		})
 	}
 
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate duplicate groups: %w", err)
+	}
+
 	return groups, nil
 }
 
+func (s *Storage) IndexArticle(ctx context.Context, id articlesim.ArticleID, keys []uint64) error {
+	docs := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		docs = append(docs, lshKey{
+			Key:       int64(k),
+			ArticleID: id,
+		})
+	}
+
+	if len(docs) == 0 {
+		return nil
+	}
+
+	if _, err := s.collectionLSHKey.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to insert lsh keys: %w", err)
+	}
+
+	return nil
+}
+
+// ReindexArticle replaces tokens of the article and its lsh keys.
+func (s *Storage) ReindexArticle(ctx context.Context, id articlesim.ArticleID, tokens articlesim.Tokens,
+	keys []uint64) error {
+	filter := bson.D{{Key: "id", Value: id}}
+	update := bson.M{
+		"$set": bson.M{"words": tokens.Words, "language": tokens.Language},
+	}
+
+	res, err := s.collectionArticle.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update article: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("failed to reindex article=%d: %w", id, articlesim.ErrArticleNotFound)
+	}
+
+	if _, err := s.collectionLSHKey.DeleteMany(ctx, bson.D{{Key: "article_id", Value: id}}); err != nil {
+		return fmt.Errorf("failed to delete lsh keys: %w", err)
+	}
+
+	return s.IndexArticle(ctx, id, keys)
+}
+
+// IndexParams reads the params document, which has no key and is never found as a key.
+func (s *Storage) IndexParams(ctx context.Context) (string, error) {
+	res := s.collectionLSHKey.FindOne(ctx, bson.M{"_id": indexParamsID})
+	if errors.Is(res.Err(), mongo.ErrNoDocuments) {
+		return "", nil
+	}
+
+	if res.Err() != nil {
+		return "", fmt.Errorf("failed to find index params: %w", res.Err())
+	}
+
+	params := indexParams{}
+	if err := res.Decode(&params); err != nil {
+		return "", fmt.Errorf("failed to decode index params: %w", err)
+	}
+
+	return params.Params, nil
+}
+
+func (s *Storage) SetIndexParams(ctx context.Context, params string) error {
+	filter := bson.M{"_id": indexParamsID}
+	update := bson.M{"$set": bson.M{"params": params}}
+
+	if _, err := s.collectionLSHKey.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to update index params: %w", err)
+	}
+
+	return nil
+}
+
+// CandidateArticles returns articles sharing at least one of the keys ordered by id.
+func (s *Storage) CandidateArticles(ctx context.Context, keys []uint64) ([]articlesim.Article, error) {
+	mkeys := make([]int64, 0, len(keys))
+	for _, k := range keys {
+		mkeys = append(mkeys, int64(k))
+	}
+
+	cur, err := s.collectionLSHKey.Find(ctx, bson.D{{Key: "key", Value: bson.D{{Key: "$in", Value: mkeys}}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lsh keys: %w", err)
+	}
+
+	defer func() {
+		if err := cur.Close(ctx); err != nil {
+			log.Printf("failed to close cursor: %v", err)
+		}
+	}()
+
+	seen := make(map[articlesim.ArticleID]struct{})
+	ids := make([]articlesim.ArticleID, 0)
+
+	for cur.Next(ctx) {
+		key := lshKey{}
+		if err := cur.Decode(&key); err != nil {
+			return nil, fmt.Errorf("failed to cursor decode to lsh key: %w", err)
+		}
+
+		if _, ok := seen[key.ArticleID]; ok {
+			continue
+		}
+
+		seen[key.ArticleID] = struct{}{}
+		ids = append(ids, key.ArticleID)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate lsh keys: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return []articlesim.Article{}, nil
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+
+	return s.find(ctx, bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}}, opts)
+}
+
+func (s *Storage) find(ctx context.Context, filter bson.D, opts *options.FindOptions) ([]articlesim.Article, error) {
+	cur, err := s.collectionArticle.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find articles: %w", err)
+	}
+
+	defer func() {
+		if err := cur.Close(ctx); err != nil {
+			log.Printf("failed to close cursor: %v", err)
+		}
+	}()
+
+	articles := make([]articlesim.Article, 0)
+
+	for cur.Next(ctx) {
+		art := article{}
+		if err := cur.Decode(&art); err != nil {
+			return nil, fmt.Errorf("failed to cursor decode to article: %w", err)
+		}
+
+		articles = append(articles, toModelArticle(art))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate articles: %w", err)
+	}
+
+	return articles, nil
+}
+
 func toModelArticle(art article) articlesim.Article {
 	return articlesim.Article{
-		ID:               art.ID,
-		Content:          art.Content,
+		ID:      art.ID,
+		Content: art.Content,
+		Metadata: articlesim.Metadata{
+			Title:       art.Title,
+			SourceURL:   art.SourceURL,
+			Author:      art.Author,
+			PublishedAt: art.PublishedAt,
+			Tags:        art.Tags,
+		},
+		Tokens: articlesim.Tokens{
+			Words:    art.Words,
+			Language: art.Language,
+		},
 		DuplicateIDs:     art.DuplicateIDs,
+		Duplicates:       toModelDuplicates(art),
 		IsUnique:         art.IsUnique,
 		DuplicateGroupID: art.DuplicateGroupID,
 	}
 }
 
+// toModelDuplicates returns links to duplicates of the article. Duplicate ids without links are read from articles
+// stored before scores were kept.
+func toModelDuplicates(art article) []articlesim.Duplicate {
+	if len(art.Duplicates) == 0 {
+		return articlesim.UnscoredDuplicates(art.DuplicateIDs)
+	}
+
+	duplicates := make([]articlesim.Duplicate, 0, len(art.Duplicates))
+	for _, d := range art.Duplicates {
+		duplicates = append(duplicates, articlesim.Duplicate{
+			ID:        d.ID,
+			Score:     d.Score,
+			Algorithm: d.Algorithm,
+			Threshold: d.Threshold,
+		})
+	}
+
+	return duplicates
+}
+
+func fromModelDuplicates(duplicates []articlesim.Duplicate) []duplicate {
+	res := make([]duplicate, 0, len(duplicates))
+	for _, d := range duplicates {
+		res = append(res, duplicate{
+			ID:        d.ID,
+			Score:     d.Score,
+			Algorithm: d.Algorithm,
+			Threshold: d.Threshold,
+		})
+	}
+
+	return res
+}
+
+// Staging returns the storage where articles are reclustered. Its collections are named with the staging suffix and
+// keep reclustered articles until they are swapped, so interrupted reclustering is resumed. The autoincrement
+// collection is shared, counters of the staging are named by its collections.
+func (s *Storage) Staging(ctx context.Context) (*Storage, error) {
+	staging := s.staging()
+	if err := staging.EnsureIndexes(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure staging indexes: %w", err)
+	}
+
+	return staging, nil
+}
+
+// SwapStaging marks the transactions counter, so transactions wait, runs catchUp and renames staging collections
+// replacing the stored ones. Marking the counter waits for the running transactions, as they update the counter too.
+// Staging transactions update their own counter, so catchUp stages articles while stored ones are not changed.
+// It fails with ErrSwapping while another storage swaps the staging.
+//
+// The mark keeps the owner storage and the lease renewed while the swap runs. When the lease is lost, the context
+// of catchUp is cancelled and the swap stops. The counter is marked for renaming before the first rename, so
+// the swap of the stopped process is completed by ResumeSwap when its lease expires and the collections are never
+// left partly swapped. When catchUp fails, the mark is removed and the staging is kept.
+func (s *Storage) SwapStaging(ctx context.Context, catchUp func(ctx context.Context) error) error {
+	if err := s.acquireSwap(ctx); err != nil {
+		return err
+	}
+
+	swapCtx, stop := s.keepSwap(ctx)
+	defer stop()
+
+	if err := catchUp(swapCtx); err != nil {
+		if rerr := s.releaseSwap(ctx); rerr != nil {
+			log.Printf("failed to unblock transactions after failed catch-up: %v", rerr)
+		}
+
+		return err
+	}
+
+	if err := s.markSwap(swapCtx, swapRenaming); err != nil {
+		return err
+	}
+
+	return s.completeSwap(swapCtx)
+}
+
+// ResumeSwap takes over the swap whose lease expired, as its process stopped. The swap stopped while renaming is
+// completed, the swap stopped while catching up unblocks transactions and its staging is kept for the next
+// reclustering. The swap of the live process is left alone.
+func (s *Storage) ResumeSwap(ctx context.Context) error {
+	filter := bson.M{
+		"collection": s.counterTransactions,
+		"swap":       bson.M{"$in": bson.A{swapBlocked, swapRenaming}},
+		"$expr":      bson.M{"$lt": bson.A{"$swap_expires", "$$NOW"}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	inc := autoincrement{}
+
+	err := s.collectionAutoincrement.FindOneAndUpdate(ctx, filter, s.leaseUpdate(nil), opts).Decode(&inc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to take over expired swap: %w", err)
+	}
+
+	if inc.Swap != swapRenaming {
+		log.Printf("unblock transactions of expired staging swap")
+
+		return s.releaseSwap(ctx)
+	}
+
+	log.Printf("complete expired staging swap")
+
+	swapCtx, stop := s.keepSwap(ctx)
+	defer stop()
+
+	return s.completeSwap(swapCtx)
+}
+
+// acquireSwap marks the transactions counter as blocked by the swap of the storage unless another swap is marked.
+// The expired swap is taken over first.
+func (s *Storage) acquireSwap(ctx context.Context) error {
+	if err := s.ResumeSwap(ctx); err != nil {
+		return err
+	}
+
+	filter := bson.M{"collection": s.counterTransactions}
+	insert := bson.M{"$setOnInsert": bson.M{"counter": 0, "updated_at": time.Now()}}
+
+	if _, err := s.collectionAutoincrement.UpdateOne(ctx, filter, insert, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to create transactions counter: %w", err)
+	}
+
+	filter = bson.M{"collection": s.counterTransactions, "swap": bson.M{"$nin": bson.A{swapBlocked, swapRenaming}}}
+
+	res, err := s.collectionAutoincrement.UpdateOne(ctx, filter, s.leaseUpdate(bson.M{"swap": swapBlocked}))
+	if err != nil {
+		return fmt.Errorf("failed to mark swap: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrSwapping
+	}
+
+	return nil
+}
+
+// keepSwap renews the lease of the swap until stop is called. When the lease is not renewed, the returned context is
+// cancelled, so the swap stops before its lease expires.
+func (s *Storage) keepSwap(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(swapRenewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.markSwap(ctx, ""); err != nil {
+					log.Printf("failed to renew staging swap lease: %v", err)
+					cancel()
+
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, func() {
+		cancel()
+		<-done
+	}
+}
+
+// markSwap renews the lease of the swap owned by the storage and marks the counter with the state unless it is
+// empty.
+func (s *Storage) markSwap(ctx context.Context, state string) error {
+	set := bson.M{}
+	if state != "" {
+		set["swap"] = state
+	}
+
+	filter := bson.M{"collection": s.counterTransactions, "swap_owner": s.owner}
+
+	res, err := s.collectionAutoincrement.UpdateOne(ctx, filter, s.leaseUpdate(set))
+	if err != nil {
+		return fmt.Errorf("failed to mark swap %q: %w", state, err)
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrSwapLeaseLost
+	}
+
+	return nil
+}
+
+// releaseSwap removes the mark of the swap owned by the storage, so transactions are unblocked.
+func (s *Storage) releaseSwap(ctx context.Context) error {
+	filter := bson.M{"collection": s.counterTransactions, "swap_owner": s.owner}
+	update := bson.M{
+		"$unset": bson.M{"swap": "", "swap_owner": "", "swap_expires": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}
+
+	res, err := s.collectionAutoincrement.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to unmark swap: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrSwapLeaseLost
+	}
+
+	return nil
+}
+
+// leaseUpdate returns the pipeline update setting the fields, the owner and the lease expiration by the server clock,
+// so clocks of processes do not matter.
+func (s *Storage) leaseUpdate(set bson.M) bson.A {
+	fields := bson.M{
+		"swap_owner":   s.owner,
+		"swap_expires": bson.M{"$add": bson.A{"$$NOW", swapLease.Milliseconds()}},
+		"updated_at":   "$$NOW",
+	}
+
+	for k, v := range set {
+		fields[k] = v
+	}
+
+	return bson.A{bson.M{"$set": fields}}
+}
+
+// completeSwap renames staging collections which are not renamed yet and unblocks transactions. Every rename
+// is atomic, articles are renamed last. The duplicate groups counter is raised to the staging one, so ids of new
+// groups do not collide with the swapped groups.
+func (s *Storage) completeSwap(ctx context.Context) error {
+	staging := s.staging()
+
+	res := staging.collectionAutoincrement.FindOne(ctx, bson.M{"collection": staging.collectionDuplicateGroup.Name()})
+	if err := res.Err(); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to find staging duplicate groups counter: %w", err)
+	}
+
+	inc := autoincrement{}
+	if err := res.Decode(&inc); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to decode staging duplicate groups counter: %w", err)
+	}
+
+	filter := bson.M{"collection": s.collectionDuplicateGroup.Name()}
+	update := bson.M{
+		"$max": bson.M{"counter": inc.Counter},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	if _, err := s.collectionAutoincrement.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to update duplicate groups counter: %w", err)
+	}
+
+	names, err := s.database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	exists := make(map[string]bool, len(names))
+	for _, name := range names {
+		exists[name] = true
+	}
+
+	for _, c := range []struct {
+		staging *mongo.Collection
+		target  *mongo.Collection
+	}{
+		{staging: staging.collectionLSHKey, target: s.collectionLSHKey},
+		{staging: staging.collectionDuplicateGroup, target: s.collectionDuplicateGroup},
+		{staging: staging.collectionArticle, target: s.collectionArticle},
+	} {
+		if !exists[c.staging.Name()] {
+			continue
+		}
+
+		if err := s.rename(ctx, c.staging, c.target); err != nil {
+			return err
+		}
+	}
+
+	filter = bson.M{"collection": staging.collectionDuplicateGroup.Name()}
+	if _, err := s.collectionAutoincrement.DeleteOne(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete staging duplicate groups counter: %w", err)
+	}
+
+	return s.releaseSwap(ctx)
+}
+
+func (s *Storage) staging() *Storage {
+	return &Storage{
+		client:                   s.client,
+		database:                 s.database,
+		collectionArticle:        s.database.Collection(s.collectionArticle.Name() + stagingSuffix),
+		collectionDuplicateGroup: s.database.Collection(s.collectionDuplicateGroup.Name() + stagingSuffix),
+		collectionAutoincrement:  s.collectionAutoincrement,
+		collectionLSHKey:         s.database.Collection(s.collectionLSHKey.Name() + stagingSuffix),
+		collectionArticleChange:  nil,
+		counterTransactions:      s.counterTransactions + stagingSuffix,
+		owner:                    s.owner,
+	}
+}
+
+// rename renames the collection to the target one dropping the target.
+func (s *Storage) rename(ctx context.Context, collection, target *mongo.Collection) error {
+	cmd := bson.D{
+		{Key: "renameCollection", Value: s.database.Name() + "." + collection.Name()},
+		{Key: "to", Value: s.database.Name() + "." + target.Name()},
+		{Key: "dropTarget", Value: true},
+	}
+
+	if err := s.client.Database("admin").RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", collection.Name(), target.Name(), err)
+	}
+
+	return nil
+}
+
 func (s *Storage) autoincrement(ctx context.Context, collection string) (*autoincrement, error) {
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
 	doc := &autoincrement{}
@@ -0,0 +1,68 @@
+package similarity
+
+import (
+	"math"
+)
+
+// Cosine represents the cosine similarity of term frequency vectors.
+// For more information see https://en.wikipedia.org/wiki/Cosine_similarity.
+//
+// Every occurrence of a word weighs the same, rare words do not weigh more than common ones.
+//
+// Threshold semantics: the similarity is the cosine of the angle between the vectors. It ignores the order of words,
+// so reordered sentences are similar, and it is close to 1 even for several edited words in a long content.
+type Cosine struct{}
+
+// NewCosine returns a new cosine metric.
+func NewCosine() *Cosine {
+	return &Cosine{}
+}
+
+// Compare returns the cosine similarity of term frequency vectors of wordsA and wordsB.
+func (m *Cosine) Compare(wordsA, wordsB []string) float64 {
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return compareSame
+	}
+
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	tfA := termFrequencies(wordsA)
+	tfB := termFrequencies(wordsB)
+
+	var dot, normA, normB float64
+
+	for word, tf := range tfA {
+		normA += tf * tf
+		dot += tf * tfB[word]
+	}
+
+	for _, tf := range tfB {
+		normB += tf * tf
+	}
+
+	return math.Min(dot/math.Sqrt(normA*normB), compareSame)
+}
+
+// DefaultThreshold returns 0.95.
+func (m *Cosine) DefaultThreshold() float64 {
+	const threshold = 0.95
+
+	return threshold
+}
+
+// Algorithm returns AlgorithmCosine.
+func (m *Cosine) Algorithm() Algorithm {
+	return AlgorithmCosine
+}
+
+func termFrequencies(words []string) map[string]float64 {
+	res := make(map[string]float64, len(words))
+
+	for _, word := range words {
+		res[word]++
+	}
+
+	return res
+}
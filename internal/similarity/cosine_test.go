@@ -0,0 +1,55 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCosine_Compare(t *testing.T) {
+	for name, tc := range map[string]struct {
+		wordsA   []string
+		wordsB   []string
+		expected float64
+	}{
+		"when empty words": {
+			wordsA:   []string{},
+			wordsB:   []string{},
+			expected: 1.0,
+		},
+		"when one empty words": {
+			wordsA:   []string{},
+			wordsB:   []string{"hello"},
+			expected: 0.0,
+		},
+		"when reordered words": {
+			wordsA:   []string{"hello", "beautiful", "world"},
+			wordsB:   []string{"world", "hello", "beautiful"},
+			expected: 1.0,
+		},
+		"when different words": {
+			wordsA:   []string{"hello", "world"},
+			wordsB:   []string{"good", "bye"},
+			expected: 0.0,
+		},
+		"when one different word": {
+			wordsA:   []string{"one", "two", "three", "four"},
+			wordsB:   []string{"one", "two", "three", "five"},
+			expected: 0.75,
+		},
+		"when repeated words": {
+			wordsA:   []string{"hello", "hello", "world"},
+			wordsB:   []string{"hello", "world"},
+			expected: 3 / math.Sqrt(10),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cos := NewCosine()
+
+			res := cos.Compare(tc.wordsA, tc.wordsB)
+
+			assert.InDelta(t, tc.expected, res, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package similarity
+
+import (
+	"unicode"
+)
+
+// English returns the profile of English language. Irregular verbs are replaced by infinitives by the normalizer
+// before stemming.
+func English() *Language {
+	return newLanguage(LanguageEnglish, unicode.Latin, englishSample, englishStopWords, PorterStemmer{})
+}
+
+const englishStopWords = `
+i me my myself we our ours ourselves you your yours yourself yourselves he him his himself she her hers herself
+it its itself they them their theirs themselves what which who whom this that these those am is are was were be
+been being have has had having do does did doing would should could ought a an the and but if or because as until
+while of at by for with about against between into through during before after above below to from up down in out
+on off over under again further then once here there when where why how all any both each few more most other some
+such no nor not only own same so than too very
+`
+
+const englishSample = `
+News agencies publish thousands of articles every day, and many of them are reprinted by other media with small
+changes. The service stores each article and finds the ones which have almost the same content. When a journalist
+writes a new story about the weather, the economy or the government, the text is compared with stories that were
+published before. If the words of both texts are nearly identical, the new article is marked as a duplicate and it
+joins the group of the original one. People who read the news want to see the original source and not the copies.
+This is why the order of articles matters: the oldest article of the group is the unique one. Readers often share
+links with their friends, and search engines should show only one version of the same story. The city council said
+on Monday that the new bridge would be open for traffic next year. Prices of food and energy have risen again, and
+the central bank is expected to raise interest rates. Scientists have found that children who spend more time
+outside are healthier and happier than those who stay at home. The football team won the match after a long and
+difficult season, and thousands of fans celebrated in the streets of the capital. There is nothing new under the
+sun, but every writer believes that their words are worth reading. Although the weather was cold, they went for a
+walk through the park and talked about the things that they had seen during the journey.
+`
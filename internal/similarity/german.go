@@ -0,0 +1,152 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// germanMinPrefix is the number of letters which must precede R1 and the valid st-ending.
+const germanMinPrefix = 3
+
+// German returns the profile of German language.
+func German() *Language {
+	return newLanguage(LanguageGerman, unicode.Latin, germanSample, germanStopWords, GermanStemmer{})
+}
+
+// GermanStemmer is the Snowball German stemmer.
+type GermanStemmer struct{}
+
+// Stem returns the stem of the lower-cased German word.
+func (GermanStemmer) Stem(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ß", "ss"))
+
+	for i := 1; i+1 < len(w); i++ {
+		if isGermanVowel(w[i-1]) && isGermanVowel(w[i+1]) {
+			switch w[i] {
+			case 'u':
+				w[i] = 'U'
+			case 'y':
+				w[i] = 'Y'
+			}
+		}
+	}
+
+	r1 := region(w, 0, isGermanVowel)
+	if r1 < germanMinPrefix {
+		r1 = germanMinPrefix
+	}
+
+	r2 := region(w, r1, isGermanVowel)
+
+	w = germanStep1(w, r1)
+	w = germanStep2(w, r1)
+	w = germanStep3(w, r1, r2)
+
+	return strings.NewReplacer("U", "u", "Y", "y", "ä", "a", "ö", "o", "ü", "u").Replace(string(w))
+}
+
+// germanStep1 removes inflectional endings em, ern, er, e, en, es and s.
+func germanStep1(w []rune, r1 int) []rune {
+	suffix := longestSuffix(w, []string{"em", "ern", "er", "e", "en", "es", "s"})
+	if suffix == "" || suffixStart(w, suffix) < r1 {
+		return w
+	}
+
+	start := suffixStart(w, suffix)
+
+	switch suffix {
+	case "s":
+		if start == 0 || !isRuneOf(w[start-1], "bdfghklmnrt") {
+			return w
+		}
+	case "e", "en", "es":
+		w = w[:start]
+		if hasSuffix(w, "niss") {
+			w = w[:len(w)-1]
+		}
+
+		return w
+	}
+
+	return w[:start]
+}
+
+// germanStep2 removes endings en, er, est and st.
+func germanStep2(w []rune, r1 int) []rune {
+	suffix := longestSuffix(w, []string{"en", "er", "est", "st"})
+	if suffix == "" || suffixStart(w, suffix) < r1 {
+		return w
+	}
+
+	start := suffixStart(w, suffix)
+
+	if suffix == "st" && (start <= germanMinPrefix || !isRuneOf(w[start-1], "bdfghklmnt")) {
+		return w
+	}
+
+	return w[:start]
+}
+
+// germanStep3 removes derivational suffixes end, ung, ig, ik, isch, lich, heit and keit.
+func germanStep3(w []rune, r1, r2 int) []rune {
+	suffix := longestSuffix(w, []string{"end", "ung", "ig", "ik", "isch", "lich", "heit", "keit"})
+	if suffix == "" || suffixStart(w, suffix) < r2 {
+		return w
+	}
+
+	start := suffixStart(w, suffix)
+
+	switch suffix {
+	case "end", "ung":
+		w = w[:start]
+		if hasSuffix(w, "ig") && suffixStart(w, "ig") >= r2 && !hasSuffix(w[:suffixStart(w, "ig")], "e") {
+			w = w[:suffixStart(w, "ig")]
+		}
+	case "ig", "ik", "isch":
+		if !hasSuffix(w[:start], "e") {
+			w = w[:start]
+		}
+	case "lich", "heit":
+		w = w[:start]
+		if s := longestSuffix(w, []string{"er", "en"}); s != "" && suffixStart(w, s) >= r1 {
+			w = w[:suffixStart(w, s)]
+		}
+	case "keit":
+		w = w[:start]
+		if s := longestSuffix(w, []string{"lich", "ig"}); s != "" && suffixStart(w, s) >= r2 {
+			w = w[:suffixStart(w, s)]
+		}
+	}
+
+	return w
+}
+
+func isGermanVowel(r rune) bool {
+	return isRuneOf(r, "aeiouyäöü")
+}
+
+const germanStopWords = `
+aber alle allem allen aller alles als also am an ander andere anderem anderen anderer anderes andern anders auch
+auf aus bei bin bis bist da damit dann das dass dem den der des die dies diese diesem diesen dieser dieses doch dort
+du durch ein eine einem einen einer eines er es etwas für hat hatte hatten haben habe hier ich ihm ihn ihr ihre im
+in ist ja jede jedem jeden jeder jedes kann kein keine mich mir mit muss nach nicht nichts noch nun nur ob oder ohne
+sehr sein seine sich sie sind so über um und uns unser unter viel vom von vor war waren was weil welche wenn wer
+werden wie wieder will wir wird wo zu zum zur zwar zwischen
+`
+
+const germanSample = `
+Nachrichtenagenturen veröffentlichen jeden Tag tausende Artikel, und viele davon werden von anderen Medien mit
+kleinen Änderungen nachgedruckt. Der Dienst speichert jeden Artikel und findet diejenigen, die fast den gleichen
+Inhalt haben. Wenn eine Journalistin eine neue Geschichte über das Wetter, die Wirtschaft oder die Regierung
+schreibt, wird der Text mit früher veröffentlichten Geschichten verglichen. Wenn die Wörter beider Texte nahezu
+gleich sind, wird der neue Artikel als Duplikat markiert und gehört zur Gruppe des ursprünglichen Artikels. Die
+Leser möchten die ursprüngliche Quelle sehen und nicht die Kopien. Deshalb ist die Reihenfolge der Artikel wichtig:
+der älteste Artikel der Gruppe ist der einzigartige. Der Stadtrat teilte am Montag mit, dass die neue Brücke im
+nächsten Jahr für den Verkehr geöffnet wird. Die Preise für Lebensmittel und Energie sind wieder gestiegen, und es
+wird erwartet, dass die Zentralbank die Zinsen erhöht. Wissenschaftler haben herausgefunden, dass Kinder, die mehr
+Zeit draußen verbringen, gesünder und glücklicher sind als diejenigen, die zu Hause bleiben. Die Mannschaft gewann
+das Spiel nach einer langen und schwierigen Saison, und tausende Fans feierten auf den Straßen der Hauptstadt.
+Obwohl das Wetter kalt war, gingen sie durch den Park spazieren und sprachen über die Dinge, die sie während der
+Reise gesehen hatten. Es gibt nichts Neues unter der Sonne, aber jeder Schriftsteller glaubt, dass seine Worte es
+wert sind, gelesen zu werden.
+`
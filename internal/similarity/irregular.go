@@ -16,15 +16,13 @@ const (
 	irregularForms = 3
 )
 
+// IrregularVerb replaces forms of irregular verbs by infinitives.
 type IrregularVerb struct {
-	verbs map[string]irregularVerb
-}
-
-type irregularVerb struct {
-	simplePast     string
-	pastParticiple string
+	// infinitives maps lower-cased verb forms to infinitives.
+	infinitives map[string]string
 }
 
+// Load loads irregular verbs from the CSV file with infinitive, simple past and past participle columns.
 func (v *IrregularVerb) Load(irregularVerbFilePath string) error {
 	file, err := os.Open(irregularVerbFilePath)
 	if err != nil {
@@ -37,9 +35,22 @@ func (v *IrregularVerb) Load(irregularVerbFilePath string) error {
 		}
 	}()
 
-	reader := csv.NewReader(file)
+	return v.Read(file)
+}
+
+// LoadDefault loads the irregular verbs built into the binary.
+func (v *IrregularVerb) LoadDefault() error {
+	return v.Read(strings.NewReader(defaultIrregularVerbs))
+}
+
+// Read reads irregular verbs in CSV format with infinitive, simple past and past participle columns and indexes
+// them by verb forms. When a form is the infinitive of one verb and a past form of another, e.g. lay, it is kept.
+func (v *IrregularVerb) Read(r io.Reader) error {
+	reader := csv.NewReader(r)
 
-	v.verbs = make(map[string]irregularVerb, irregularVerbs)
+	v.infinitives = make(map[string]string, irregularVerbs*irregularForms)
+
+	var infinitives []string
 
 	for {
 		record, err := reader.Read()
@@ -55,27 +66,123 @@ func (v *IrregularVerb) Load(irregularVerbFilePath string) error {
 			continue
 		}
 
-		infinitive := record[0]
-		simplePast := record[1]
-		pastParticiple := record[2]
+		infinitive := strings.ToLower(record[0])
+		infinitives = append(infinitives, infinitive)
 
-		v.verbs[infinitive] = irregularVerb{
-			simplePast:     simplePast,
-			pastParticiple: pastParticiple,
+		for _, form := range record[1:irregularForms] {
+			v.infinitives[strings.ToLower(form)] = infinitive
 		}
 	}
 
+	for _, infinitive := range infinitives {
+		v.infinitives[infinitive] = infinitive
+	}
+
 	return nil
 }
 
+// ToInfinitive returns the infinitive of the irregular verb form or the verb itself if it is not an irregular verb.
 func (v *IrregularVerb) ToInfinitive(verb string) string {
-	for infinitive, irregular := range v.verbs {
-		for _, verbForm := range []string{infinitive, irregular.simplePast, irregular.pastParticiple} {
-			if strings.EqualFold(verbForm, verb) {
-				return infinitive
-			}
-		}
+	if infinitive, ok := v.infinitives[strings.ToLower(verb)]; ok {
+		return infinitive
 	}
 
 	return verb
 }
+
+// defaultIrregularVerbs are infinitive, simple past and past participle of common English irregular verbs.
+const defaultIrregularVerbs = `
+awake,awoke,awoken
+be,was,been
+beat,beat,beaten
+become,became,become
+begin,began,begun
+bend,bent,bent
+bet,bet,bet
+bid,bid,bid
+bite,bit,bitten
+blow,blew,blown
+break,broke,broken
+bring,brought,brought
+broadcast,broadcast,broadcast
+build,built,built
+burn,burned,burned
+buy,bought,bought
+catch,caught,caught
+choose,chose,chosen
+come,came,come
+cost,cost,cost
+cut,cut,cut
+dig,dug,dug
+do,did,done
+draw,drew,drawn
+dream,dreamt,dreamt
+drive,drove,driven
+drink,drank,drunk
+eat,ate,eaten
+fall,fell,fallen
+feel,felt,felt
+fight,fought,fought
+find,found,found
+fly,flew,flown
+forget,forgot,forgotten
+forgive,forgave,forgiven
+freeze,froze,frozen
+get,got,got
+give,gave,given
+go,went,gone
+grow,grew,grown
+hang,hung,hung
+have,had,had
+hear,heard,heard
+hide,hid,hidden
+hit,hit,hit
+hold,held,held
+hurt,hurt,hurt
+keep,kept,kept
+know,knew,known
+lay,laid,laid
+lead,led,led
+learn,learnt,learnt
+leave,left,left
+lend,lent,lent
+let,let,let
+lie,lay,lain
+lose,lost,lost
+make,made,made
+mean,meant,meant
+meet,met,met
+pay,paid,paid
+put,put,put
+read,read,read
+ride,rode,ridden
+ring,rang,rung
+rise,rose,risen
+run,ran,run
+say,said,said
+see,saw,seen
+sell,sold,sold
+send,sent,sent
+show,showed,shown
+shut,shut,shut
+sing,sang,sung
+sink,sank,sunk
+sit,sat,sat
+sleep,slept,slept
+speak,spoke,spoken
+spend,spent,spent
+stand,stood,stood
+stink,stank,stunk
+swim,swam,swum
+take,took,taken
+teach,taught,taught
+tear,tore,torn
+tell,told,told
+think,thought,thought
+throw,threw,thrown
+understand,understood,understood
+wake,woke,woken
+wear,wore,worn
+win,won,won
+write,wrote,written
+`
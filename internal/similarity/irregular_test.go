@@ -0,0 +1,60 @@
+package similarity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIrregularVerb_ToInfinitive(t *testing.T) {
+	irregular := IrregularVerb{}
+	require.NoError(t, irregular.LoadDefault())
+
+	for name, tc := range map[string]struct {
+		verb     string
+		expected string
+	}{
+		"when infinitive": {
+			verb:     "go",
+			expected: "go",
+		},
+		"when simple past": {
+			verb:     "went",
+			expected: "go",
+		},
+		"when past participle": {
+			verb:     "gone",
+			expected: "go",
+		},
+		"when different case": {
+			verb:     "Went",
+			expected: "go",
+		},
+		"when infinitive is past form of another verb": {
+			verb:     "lay",
+			expected: "lay",
+		},
+		"when regular verb": {
+			verb:     "walked",
+			expected: "walked",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, irregular.ToInfinitive(tc.verb))
+		})
+	}
+}
+
+func TestIrregularVerb_Read(t *testing.T) {
+	irregular := IrregularVerb{}
+
+	err := irregular.Read(strings.NewReader("arise,arose,arisen\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "arise", irregular.ToInfinitive("arisen"))
+	assert.Equal(t, "went", irregular.ToInfinitive("went"))
+}
@@ -0,0 +1,79 @@
+package similarity
+
+import (
+	"strings"
+)
+
+const defaultShingleSize = 2
+
+// Jaccard represents the Jaccard index over word shingles.
+// For more information see https://en.wikipedia.org/wiki/Jaccard_index and https://en.wikipedia.org/wiki/W-shingling.
+//
+// Threshold semantics: the similarity is the share of common shingles among all distinct shingles. The metric
+// ignores how often and where shingles occur, so it is faster and more tolerant to moved paragraphs than Levenshtein.
+// Every edited word changes up to ShingleSize shingles, so thresholds are lower than for Levenshtein.
+type Jaccard struct {
+	// ShingleSize represents the number of consecutive words in a shingle.
+	ShingleSize int
+}
+
+// NewJaccard returns a new Jaccard metric.
+//
+// Default options:
+//   ShingleSize: 2
+func NewJaccard() *Jaccard {
+	return &Jaccard{
+		ShingleSize: defaultShingleSize,
+	}
+}
+
+// Compare returns the Jaccard index of word shingles of wordsA and wordsB.
+func (m *Jaccard) Compare(wordsA, wordsB []string) float64 {
+	shinglesA := m.shingles(wordsA)
+	shinglesB := m.shingles(wordsB)
+
+	if len(shinglesA) == 0 && len(shinglesB) == 0 {
+		return compareSame
+	}
+
+	intersection := 0
+
+	for shingle := range shinglesA {
+		if _, ok := shinglesB[shingle]; ok {
+			intersection++
+		}
+	}
+
+	union := len(shinglesA) + len(shinglesB) - intersection
+
+	return float64(intersection) / float64(union)
+}
+
+// DefaultThreshold returns 0.8.
+func (m *Jaccard) DefaultThreshold() float64 {
+	const threshold = 0.8
+
+	return threshold
+}
+
+// Algorithm returns AlgorithmJaccard.
+func (m *Jaccard) Algorithm() Algorithm {
+	return AlgorithmJaccard
+}
+
+// shingles returns the set of consecutive words sequences. Content shorter than the shingle size is a single shingle.
+func (m *Jaccard) shingles(words []string) map[string]struct{} {
+	res := make(map[string]struct{}, len(words))
+
+	if len(words) == 0 {
+		return res
+	}
+
+	size := Max(Min(m.ShingleSize, len(words)), 1)
+
+	for i := 0; i+size <= len(words); i++ {
+		res[strings.Join(words[i:i+size], " ")] = struct{}{}
+	}
+
+	return res
+}
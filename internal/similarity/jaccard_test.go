@@ -0,0 +1,49 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaccard_Compare(t *testing.T) {
+	for name, tc := range map[string]struct {
+		wordsA   []string
+		wordsB   []string
+		expected float64
+	}{
+		"when empty words": {
+			wordsA:   []string{},
+			wordsB:   []string{},
+			expected: 1.0,
+		},
+		"when one empty words": {
+			wordsA:   []string{},
+			wordsB:   []string{"hello"},
+			expected: 0.0,
+		},
+		"when words shorter than shingle": {
+			wordsA:   []string{"hello"},
+			wordsB:   []string{"hello"},
+			expected: 1.0,
+		},
+		"when reordered sentences": {
+			wordsA:   []string{"hello", "world", "good", "bye"},
+			wordsB:   []string{"good", "bye", "hello", "world"},
+			expected: 0.5,
+		},
+		"when one word differs": {
+			wordsA:   []string{"one", "two", "three", "four"},
+			wordsB:   []string{"one", "two", "three", "five"},
+			expected: 0.5,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			jac := NewJaccard()
+
+			res := jac.Compare(tc.wordsA, tc.wordsB)
+
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package similarity
+
+const (
+	defaultPrefixScale = 0.1
+	maxPrefixLength    = 4
+)
+
+// JaroWinkler represents the Jaro-Winkler metric over words.
+// For more information see https://en.wikipedia.org/wiki/Jaro%E2%80%93Winkler_distance.
+//
+// Words are compared as sequence elements, not characters, so the metric stays fast for long contents.
+// Threshold semantics: the Jaro similarity counts words matched within a window of half of the longer content and
+// penalizes matched words out of order. The Winkler modification rewards a common prefix of up to 4 words.
+// Scores are higher than Levenshtein for the same edits, so thresholds are closer to 1.
+type JaroWinkler struct {
+	// PrefixScale represents how much the score is adjusted upwards for having a common prefix.
+	// It should not exceed 0.25, otherwise the similarity could become larger than 1.
+	PrefixScale float64
+}
+
+// NewJaroWinkler returns a new Jaro-Winkler metric.
+//
+// Default options:
+//   PrefixScale: 0.1
+func NewJaroWinkler() *JaroWinkler {
+	return &JaroWinkler{
+		PrefixScale: defaultPrefixScale,
+	}
+}
+
+// Compare returns the Jaro-Winkler similarity of wordsA and wordsB.
+func (m *JaroWinkler) Compare(wordsA, wordsB []string) float64 {
+	jaro := m.Jaro(wordsA, wordsB)
+
+	prefix := 0
+	for prefix < Min(len(wordsA), len(wordsB), maxPrefixLength) && wordsA[prefix] == wordsB[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*m.PrefixScale*(compareSame-jaro)
+}
+
+// DefaultThreshold returns 0.97.
+func (m *JaroWinkler) DefaultThreshold() float64 {
+	const threshold = 0.97
+
+	return threshold
+}
+
+// Algorithm returns AlgorithmJaroWinkler.
+func (m *JaroWinkler) Algorithm() Algorithm {
+	return AlgorithmJaroWinkler
+}
+
+// Jaro returns the Jaro similarity of wordsA and wordsB.
+func (m *JaroWinkler) Jaro(wordsA, wordsB []string) float64 {
+	lenA, lenB := len(wordsA), len(wordsB)
+	if lenA == 0 && lenB == 0 {
+		return compareSame
+	}
+
+	if lenA == 0 || lenB == 0 {
+		return 0
+	}
+
+	window := Max(Max(lenA, lenB)/2-1, 0)
+
+	matchedA := make([]bool, lenA)
+	matchedB := make([]bool, lenB)
+	matches := 0
+
+	for i := 0; i < lenA; i++ {
+		for j := Max(0, i-window); j < Min(lenB, i+window+1); j++ {
+			if matchedB[j] || wordsA[i] != wordsB[j] {
+				continue
+			}
+
+			matchedA[i] = true
+			matchedB[j] = true
+			matches++
+
+			break
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+
+	for i := 0; i < lenA; i++ {
+		if !matchedA[i] {
+			continue
+		}
+
+		for !matchedB[j] {
+			j++
+		}
+
+		if wordsA[i] != wordsB[j] {
+			transpositions++
+		}
+
+		j++
+	}
+
+	const parts = 3
+
+	m64 := float64(matches)
+
+	return (m64/float64(lenA) + m64/float64(lenB) + (m64-float64(transpositions)/2)/m64) / parts
+}
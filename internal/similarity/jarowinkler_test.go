@@ -0,0 +1,52 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaroWinkler_Compare(t *testing.T) {
+	for name, tc := range map[string]struct {
+		wordsA   []string
+		wordsB   []string
+		expected float64
+	}{
+		"when empty words": {
+			wordsA:   []string{},
+			wordsB:   []string{},
+			expected: 1.0,
+		},
+		"when one empty words": {
+			wordsA:   []string{},
+			wordsB:   []string{"hello"},
+			expected: 0.0,
+		},
+		"when equal words": {
+			wordsA:   []string{"hello", "world"},
+			wordsB:   []string{"hello", "world"},
+			expected: 1.0,
+		},
+		"when transposed words": {
+			wordsA:   []string{"m", "a", "r", "t", "h", "a"},
+			wordsB:   []string{"m", "a", "r", "h", "t", "a"},
+			expected: 0.9611111111111111,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			jw := NewJaroWinkler()
+
+			res := jw.Compare(tc.wordsA, tc.wordsB)
+
+			assert.InDelta(t, tc.expected, res, 1e-9)
+		})
+	}
+}
+
+func TestJaroWinkler_Jaro(t *testing.T) {
+	jw := NewJaroWinkler()
+
+	res := jw.Jaro([]string{"m", "a", "r", "t", "h", "a"}, []string{"m", "a", "r", "h", "t", "a"})
+
+	assert.InDelta(t, 0.9444444444444445, res, 1e-9)
+}
@@ -0,0 +1,227 @@
+package similarity
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ISO 639-1 codes of supported languages.
+const (
+	LanguageEnglish   = "en"
+	LanguageGerman    = "de"
+	LanguageRussian   = "ru"
+	LanguageUkrainian = "uk"
+
+	// LanguageUndetermined is the code of the content which language is not detected.
+	LanguageUndetermined = "und"
+)
+
+const (
+	// ngramMaxLen is the length of the longest n-gram of the language profile.
+	ngramMaxLen = 3
+	// profileSize is the number of the most frequent n-grams in the language profile.
+	profileSize = 300
+)
+
+// Stemmer reduces a word to its stem.
+type Stemmer interface {
+	Stem(word string) string
+}
+
+// Language is a language profile: n-grams to detect the language, stop words and the stemmer of its words.
+type Language struct {
+	// Code is the ISO 639-1 language code.
+	Code string
+	// Stemmer is nil when words of the language are not stemmed.
+	Stemmer Stemmer
+
+	stopWords map[string]struct{}
+	script    *unicode.RangeTable
+	profile   map[string]int
+}
+
+func newLanguage(code string, script *unicode.RangeTable, sample, stopWords string, stemmer Stemmer) *Language {
+	words := strings.Fields(stopWords)
+
+	stop := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		stop[w] = struct{}{}
+	}
+
+	return &Language{
+		Code:      code,
+		Stemmer:   stemmer,
+		stopWords: stop,
+		script:    script,
+		profile:   ngramProfile(sample),
+	}
+}
+
+// Languages returns profiles of all supported languages.
+func Languages() []*Language {
+	return []*Language{English(), German(), Russian(), Ukrainian()}
+}
+
+// DetectLanguage returns the language of the text or nil when the text is not written in any of the languages.
+// Only languages of the prevailing script of the text are compared with the n-gram profile of the text.
+func DetectLanguage(text string, languages []*Language) *Language {
+	script := prevailingScript(text, languages)
+	if script == nil {
+		return nil
+	}
+
+	doc := ngramProfile(text)
+
+	var (
+		detected *Language
+		distance int
+	)
+
+	for _, lang := range languages {
+		if lang.script != script {
+			continue
+		}
+
+		if d := lang.distance(doc); detected == nil || d < distance {
+			detected, distance = lang, d
+		}
+	}
+
+	return detected
+}
+
+// distance returns the out-of-place measure of the n-gram ranks of the document and the language.
+func (l *Language) distance(doc map[string]int) int {
+	distance := 0
+
+	for gram, rank := range doc {
+		langRank, ok := l.profile[gram]
+		if !ok {
+			distance += profileSize
+
+			continue
+		}
+
+		if rank > langRank {
+			distance += rank - langRank
+		} else {
+			distance += langRank - rank
+		}
+	}
+
+	return distance
+}
+
+// isStopWord reports whether the word is a stop word of the language. Nil language has no stop words.
+func (l *Language) isStopWord(word string) bool {
+	if l == nil {
+		return false
+	}
+
+	_, ok := l.stopWords[word]
+
+	return ok
+}
+
+// stem returns the stem of the word. Nil language and language without stemmer leave the word as is.
+func (l *Language) stem(word string) string {
+	if l == nil || l.Stemmer == nil {
+		return word
+	}
+
+	return l.Stemmer.Stem(word)
+}
+
+// code returns the language code or LanguageUndetermined for nil language.
+func (l *Language) code() string {
+	if l == nil {
+		return LanguageUndetermined
+	}
+
+	return l.Code
+}
+
+// prevailingScript returns the script of the languages which most letters of the text belong to.
+// It returns nil when the text has more letters of other scripts.
+func prevailingScript(text string, languages []*Language) *unicode.RangeTable {
+	counts := make(map[*unicode.RangeTable]int, len(languages))
+	other := 0
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		known := false
+
+		for _, lang := range languages {
+			if unicode.Is(lang.script, r) {
+				counts[lang.script]++
+				known = true
+
+				break
+			}
+		}
+
+		if !known {
+			other++
+		}
+	}
+
+	var script *unicode.RangeTable
+
+	most := other
+
+	for _, lang := range languages {
+		if counts[lang.script] > most {
+			script, most = lang.script, counts[lang.script]
+		}
+	}
+
+	return script
+}
+
+// ngramProfile returns ranks of the most frequent n-grams of lower-cased words of the text.
+// Words are padded with spaces to tell the n-grams at the beginning and the end of words.
+func ngramProfile(text string) map[string]int {
+	counts := make(map[string]int)
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		runes := []rune(" " + word + " ")
+
+		for n := 1; n <= ngramMaxLen; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if gram := string(runes[i : i+n]); gram != " " {
+					counts[gram]++
+				}
+			}
+		}
+	}
+
+	grams := make([]string, 0, len(counts))
+	for gram := range counts {
+		grams = append(grams, gram)
+	}
+
+	sort.Slice(grams, func(i, j int) bool {
+		if counts[grams[i]] != counts[grams[j]] {
+			return counts[grams[i]] > counts[grams[j]]
+		}
+
+		return grams[i] < grams[j]
+	})
+
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+
+	profile := make(map[string]int, len(grams))
+	for rank, gram := range grams {
+		profile[gram] = rank
+	}
+
+	return profile
+}
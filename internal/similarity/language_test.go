@@ -0,0 +1,75 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	for name, tc := range map[string]struct {
+		text     string
+		expected string
+	}{
+		"when english": {
+			text:     "The quick brown fox jumps over the lazy dog",
+			expected: LanguageEnglish,
+		},
+		"when german": {
+			text:     "Der Hund läuft schnell nach Hause",
+			expected: LanguageGerman,
+		},
+		"when russian": {
+			text:     "Кот сидит на окне и смотрит на улицу",
+			expected: LanguageRussian,
+		},
+		"when ukrainian": {
+			text:     "Кіт сидить на вікні та дивиться на вулицю",
+			expected: LanguageUkrainian,
+		},
+		"when unsupported script": {
+			text:     "我爱北京",
+			expected: LanguageUndetermined,
+		},
+		"when no letters": {
+			text:     "42, 2020!",
+			expected: LanguageUndetermined,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			lang := DetectLanguage(tc.text, Languages())
+
+			assert.Equal(t, tc.expected, lang.code())
+		})
+	}
+}
+
+func TestNormalizer_Words_Languages(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content  string
+		expected []string
+	}{
+		"when german": {
+			content:  "Die Häuser der Stadt",
+			expected: []string{"haus", "stadt"},
+		},
+		"when russian": {
+			content:  "Читали новые статьи",
+			expected: []string{"чита", "нов", "стат"},
+		},
+		"when ukrainian": {
+			content:  "Читали нові статті",
+			expected: []string{"чита", "нов", "стат"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			n := DefaultNormalizer(IrregularVerb{})
+
+			assert.Equal(t, tc.expected, n.Words(tc.content))
+		})
+	}
+}
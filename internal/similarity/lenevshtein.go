@@ -1,16 +1,32 @@
 package similarity
 
+import (
+	"fmt"
+	"math"
+)
+
 // Levenshtein represents the Levenshtein metric for measuring the similarity between sequences.
 //   For more information see https://en.wikipedia.org/wiki/Levenshtein_distance.
+//
+// The distance is computed over sequences of uint32 token ids, so the matrix cells compare integers without
+// allocations. Methods over elements, characters and words intern them into token ids first.
+//
+// With positive TransposeCost it is the optimal string alignment distance, the restricted Damerau-Levenshtein
+// distance: swapping two adjacent elements is a single edit, and no element is edited again after a transposition.
+//   For more information see https://en.wikipedia.org/wiki/Damerau%E2%80%93Levenshtein_distance.
 type Levenshtein struct {
 	// InsertCost represents the Levenshtein cost of a character insertion.
 	InsertCost int
 
-	// InsertCost represents the Levenshtein cost of a character deletion.
+	// DeleteCost represents the Levenshtein cost of a character deletion.
 	DeleteCost int
 
-	// InsertCost represents the Levenshtein cost of a character substitution.
+	// ReplaceCost represents the Levenshtein cost of a character substitution.
 	ReplaceCost int
+
+	// TransposeCost represents the cost of a transposition of two adjacent characters. Transpositions are not edits
+	// when it is zero.
+	TransposeCost int
 }
 
 // NewLevenshtein returns a new Levenshtein metric.
@@ -19,43 +35,126 @@ type Levenshtein struct {
 //   InsertCost: 1
 //   DeleteCost: 1
 //   ReplaceCost: 1
+//   TransposeCost: 0
 func NewLevenshtein() *Levenshtein {
 	return &Levenshtein{
-		InsertCost:  1,
-		DeleteCost:  1,
-		ReplaceCost: 1,
+		InsertCost:    1,
+		DeleteCost:    1,
+		ReplaceCost:   1,
+		TransposeCost: 0,
+	}
+}
+
+// NewDamerauLevenshtein returns a new optimal string alignment metric.
+//
+// Default options:
+//   InsertCost: 1
+//   DeleteCost: 1
+//   ReplaceCost: 1
+//   TransposeCost: 1
+func NewDamerauLevenshtein() *Levenshtein {
+	return &Levenshtein{
+		InsertCost:    1,
+		DeleteCost:    1,
+		ReplaceCost:   1,
+		TransposeCost: 1,
+	}
+}
+
+// Validate checks that costs of insertion, deletion and substitution are positive and the cost of transposition is
+// not negative.
+func (m *Levenshtein) Validate() error {
+	if m.InsertCost <= 0 || m.DeleteCost <= 0 || m.ReplaceCost <= 0 || m.TransposeCost < 0 {
+		return fmt.Errorf("%w: insert=%d, delete=%d, replace=%d, transpose=%d", ErrInvalidCost,
+			m.InsertCost, m.DeleteCost, m.ReplaceCost, m.TransposeCost)
 	}
+
+	return nil
 }
 
 // Element is a sequence element.
 type Element interface{}
 
-// CompareFn is function to compare elements.
+// CompareFn is function to compare elements. The nil function compares elements with ==.
 type CompareFn func(a, b Element) bool
 
+// DefaultCompareFn returns the nil function, so elements are compared with == and interned through a map.
 func DefaultCompareFn() CompareFn {
-	return func(a, b Element) bool { return a == b }
+	return nil
 }
 
+// WeightFn is function to weigh words.
+type WeightFn func(word string) float64
+
 const compareSame = 1.0
 
 // Compare returns the Levenshtein similarity of sequenceA and sequenceB. Sequences is comparing with compare function.
 // The returned similarity is a number between 0 and 1. Larger similarity numbers indicate closer matches.
+//
+// The similarity is 1 - distance/worst, where worst is the distance of sequences of the same lengths without equal
+// elements: min(n, m) substitutions, or deletions with insertions when they are cheaper, and the remaining deletions
+// or insertions. With unit costs worst is max(n, m).
 func (m *Levenshtein) Compare(sequenceA, sequenceB []Element, compare CompareFn) float64 {
-	distance := m.Distance(sequenceA, sequenceB, compare)
-	if distance == 0 {
-		return compareSame
-	}
+	idsA, idsB := internElements(sequenceA, sequenceB, compare)
+
+	return m.CompareIDs(idsA, idsB)
+}
 
-	maxLen := Max(len(sequenceA), len(sequenceB))
+// CompareBounded returns the Levenshtein similarity of sequenceA and sequenceB and true when it reaches
+// the threshold. Otherwise it returns false as soon as the threshold is known to be unreachable and the similarity
+// is not computed. The threshold caps the distance at (1-threshold)*worst, so the distance is computed by
+// BoundedDistance.
+func (m *Levenshtein) CompareBounded(sequenceA, sequenceB []Element, compare CompareFn,
+	threshold float64) (float64, bool) {
+	idsA, idsB := internElements(sequenceA, sequenceB, compare)
 
-	return compareSame - float64(distance)/float64(maxLen)
+	return m.CompareBoundedIDs(idsA, idsB, threshold)
 }
 
 // Distance returns the Levenshtein distance between sequenceA and sequenceB. Sequences is comparing with compare
 // function. Lower distances indicate closer matches. A distance of 0 means the strings are identical.
 func (m *Levenshtein) Distance(sequenceA, sequenceB []Element, compare CompareFn) int {
-	lenA, lenB := len(sequenceA), len(sequenceB)
+	idsA, idsB := internElements(sequenceA, sequenceB, compare)
+
+	return m.DistanceIDs(idsA, idsB)
+}
+
+// BoundedDistance returns the Levenshtein distance between sequenceA and sequenceB and true when it does not exceed
+// maxDistance. Otherwise it returns false as soon as the distance is known to exceed maxDistance.
+func (m *Levenshtein) BoundedDistance(sequenceA, sequenceB []Element, compare CompareFn,
+	maxDistance int) (int, bool) {
+	idsA, idsB := internElements(sequenceA, sequenceB, compare)
+
+	return m.BoundedDistanceIDs(idsA, idsB, maxDistance)
+}
+
+// CompareIDs returns the Levenshtein similarity of token ids sequences idsA and idsB.
+func (m *Levenshtein) CompareIDs(idsA, idsB []uint32) float64 {
+	distance := m.DistanceIDs(idsA, idsB)
+
+	return levenshteinScore(distance, m.worstDistance(len(idsA), len(idsB)))
+}
+
+// CompareBoundedIDs returns the Levenshtein similarity of token ids sequences idsA and idsB and true when it reaches
+// the threshold like CompareBounded.
+func (m *Levenshtein) CompareBoundedIDs(idsA, idsB []uint32, threshold float64) (float64, bool) {
+	worst := m.worstDistance(len(idsA), len(idsB))
+	if worst == 0 {
+		return compareSame, compareSame >= threshold
+	}
+
+	distance, ok := m.BoundedDistanceIDs(idsA, idsB, maxSimilarDistance(worst, threshold))
+	if !ok {
+		return 0, false
+	}
+
+	return levenshteinScore(distance, worst), true
+}
+
+// DistanceIDs returns the Levenshtein distance between token ids sequences idsA and idsB. It allocates three columns
+// of the matrix regardless of the sequences lengths.
+func (m *Levenshtein) DistanceIDs(idsA, idsB []uint32) int {
+	lenA, lenB := len(idsA), len(idsB)
 	if lenA == 0 && lenB == 0 {
 		return 0
 	}
@@ -69,76 +168,526 @@ func (m *Levenshtein) Distance(sequenceA, sequenceB []Element, compare CompareFn
 	}
 
 	prevCol := make([]int, lenB+1)
-	for i := 0; i <= lenB; i++ {
-		prevCol[i] = i
+	for j := range prevCol {
+		prevCol[j] = j * m.InsertCost
 	}
 
+	// prevPrevCol is the row before prevCol, transpositions continue its cells.
+	prevPrevCol := make([]int, lenB+1)
 	col := make([]int, lenB+1)
+
 	for i := 0; i < lenA; i++ {
-		col[0] = i + 1
+		col[0] = (i + 1) * m.DeleteCost
+		a := idsA[i]
 
-		for j := 0; j < lenB; j++ {
-			delCost := prevCol[j+1] + m.DeleteCost
-			insCost := col[j] + m.InsertCost
+		for j, b := range idsB {
+			cost := prevCol[j]
+			if a != b {
+				cost += m.ReplaceCost
+			}
 
-			subCost := prevCol[j]
-			if !compare(sequenceA[i], sequenceB[j]) {
-				subCost += m.ReplaceCost
+			if del := prevCol[j+1] + m.DeleteCost; del < cost {
+				cost = del
 			}
 
-			col[j+1] = Min(delCost, insCost, subCost)
+			if ins := col[j] + m.InsertCost; ins < cost {
+				cost = ins
+			}
+
+			if m.transposed(idsA, idsB, i+1, j+1) && prevPrevCol[j-1]+m.TransposeCost < cost {
+				cost = prevPrevCol[j-1] + m.TransposeCost
+			}
+
+			col[j+1] = cost
 		}
 
-		col, prevCol = prevCol, col
+		prevPrevCol, prevCol, col = prevCol, col, prevPrevCol
 	}
 
 	return prevCol[lenB]
 }
 
+// BoundedDistanceIDs returns the Levenshtein distance between token ids sequences idsA and idsB and true when it
+// does not exceed maxDistance. Otherwise it returns false as soon as the distance is known to exceed maxDistance.
+//
+// Sequences which lengths differ by more insertions or deletions than maxDistance allows are rejected without
+// computation. Otherwise only the diagonal band of the matrix is filled (Ukkonen): a cell farther from the diagonal
+// than maxDistance/min(InsertCost, DeleteCost) is reached only by exceeding insertions or deletions, transpositions
+// keep the distance from the diagonal. The computation stops at the first two adjacent rows which cells of the band
+// all exceed maxDistance, since a transposition skips a single row, so different sequences are rejected after a few
+// rows. It takes O(maxDistance*min(n, m)) instead of O(n*m).
+func (m *Levenshtein) BoundedDistanceIDs(idsA, idsB []uint32, maxDistance int) (int, bool) {
+	if maxDistance < 0 {
+		return 0, false
+	}
+
+	minCost := Min(m.InsertCost, m.DeleteCost)
+	if minCost <= 0 {
+		distance := m.DistanceIDs(idsA, idsB)
+
+		return distance, distance <= maxDistance
+	}
+
+	lenA, lenB := len(idsA), len(idsB)
+	if Max(lenA-lenB, lenB-lenA)*minCost > maxDistance {
+		return 0, false
+	}
+
+	if lenA == 0 || lenB == 0 {
+		distance := m.InsertCost*lenB + m.DeleteCost*lenA
+
+		return distance, distance <= maxDistance
+	}
+
+	band := maxDistance / minCost
+	// exceeded marks cells out of the band, all of them exceed maxDistance.
+	exceeded := maxDistance + 1
+
+	prevCol := make([]int, lenB+1)
+	for j := range prevCol {
+		prevCol[j] = exceeded
+		if j <= band {
+			prevCol[j] = j * m.InsertCost
+		}
+	}
+
+	prevPrevCol := make([]int, lenB+1)
+	col := make([]int, lenB+1)
+	prevRowMin := 0
+
+	for i := 1; i <= lenA; i++ {
+		lo, hi := Max(1, i-band), Min(lenB, i+band)
+
+		col[lo-1] = exceeded
+		if lo == 1 {
+			col[0] = i * m.DeleteCost
+		}
+
+		rowMin := col[lo-1]
+		a := idsA[i-1]
+
+		for j := lo; j <= hi; j++ {
+			cost := prevCol[j-1]
+			if a != idsB[j-1] {
+				cost += m.ReplaceCost
+			}
+
+			if del := prevCol[j] + m.DeleteCost; del < cost {
+				cost = del
+			}
+
+			if ins := col[j-1] + m.InsertCost; ins < cost {
+				cost = ins
+			}
+
+			// The cell two rows and columns back is on the same diagonal, so it is in the band.
+			if m.transposed(idsA, idsB, i, j) && prevPrevCol[j-2]+m.TransposeCost < cost {
+				cost = prevPrevCol[j-2] + m.TransposeCost
+			}
+
+			col[j] = cost
+
+			if cost < rowMin {
+				rowMin = cost
+			}
+		}
+
+		if hi < lenB {
+			col[hi+1] = exceeded
+		}
+
+		if rowMin > maxDistance && prevRowMin > maxDistance {
+			return 0, false
+		}
+
+		prevRowMin = rowMin
+		prevPrevCol, prevCol, col = prevCol, col, prevPrevCol
+	}
+
+	if prevCol[lenB] > maxDistance {
+		return 0, false
+	}
+
+	return prevCol[lenB], true
+}
+
+// EditOperation is a kind of the edit turning one sequence into another.
+type EditOperation string
+
+const (
+	EditInsert     EditOperation = "insert"
+	EditDelete     EditOperation = "delete"
+	EditSubstitute EditOperation = "substitute"
+	EditTranspose  EditOperation = "transpose"
+)
+
+// Edit is an operation of the edit script. IndexA is the position in sequenceA and IndexB is the position
+// in sequenceB: the deleted element is sequenceA[IndexA], the inserted element is sequenceB[IndexB]. Transposition
+// swaps sequenceA[IndexA] and sequenceA[IndexA+1] into sequenceB[IndexB] and sequenceB[IndexB+1].
+type Edit struct {
+	Operation EditOperation
+	IndexA    int
+	IndexB    int
+}
+
+// EditScript returns the cheapest edits turning sequenceA into sequenceB ordered by position. Equal elements
+// are not included. Sequences is comparing with compare function. Among edits of the same cost deletions and
+// insertions are preferred to substitutions, so the script keeps equal elements aligned.
+func (m *Levenshtein) EditScript(sequenceA, sequenceB []Element, compare CompareFn) []Edit {
+	idsA, idsB := internElements(sequenceA, sequenceB, compare)
+
+	return m.EditScriptIDs(idsA, idsB)
+}
+
+// EditScriptIDs returns the edit script turning token ids sequence idsA into idsB like EditScript.
+func (m *Levenshtein) EditScriptIDs(idsA, idsB []uint32) []Edit {
+	lenA, lenB := len(idsA), len(idsB)
+	width := lenB + 1
+
+	// dist is the whole matrix in a single allocation, dist[i*width+j] is the distance of prefixes of i and j ids.
+	dist := make([]int, (lenA+1)*width)
+	for i := 0; i <= lenA; i++ {
+		dist[i*width] = i * m.DeleteCost
+	}
+
+	for j := 0; j <= lenB; j++ {
+		dist[j] = j * m.InsertCost
+	}
+
+	for i := 1; i <= lenA; i++ {
+		for j := 1; j <= lenB; j++ {
+			subCost := dist[(i-1)*width+j-1]
+			if idsA[i-1] != idsB[j-1] {
+				subCost += m.ReplaceCost
+			}
+
+			dist[i*width+j] = Min(dist[(i-1)*width+j]+m.DeleteCost, dist[i*width+j-1]+m.InsertCost, subCost)
+
+			if m.transposed(idsA, idsB, i, j) {
+				dist[i*width+j] = Min(dist[i*width+j], dist[(i-2)*width+j-2]+m.TransposeCost)
+			}
+		}
+	}
+
+	edits := make([]Edit, 0, Max(lenA, lenB))
+
+	for i, j := lenA, lenB; i > 0 || j > 0; {
+		cell := dist[i*width+j]
+
+		switch {
+		case i > 0 && j > 0 && idsA[i-1] == idsB[j-1] && cell == dist[(i-1)*width+j-1]:
+			i--
+			j--
+
+			continue
+		case m.transposed(idsA, idsB, i, j) && cell == dist[(i-2)*width+j-2]+m.TransposeCost:
+			i -= 2
+			j -= 2
+			edits = append(edits, Edit{Operation: EditTranspose, IndexA: i, IndexB: j})
+		case i > 0 && cell == dist[(i-1)*width+j]+m.DeleteCost:
+			i--
+			edits = append(edits, Edit{Operation: EditDelete, IndexA: i, IndexB: j})
+		case j > 0 && cell == dist[i*width+j-1]+m.InsertCost:
+			j--
+			edits = append(edits, Edit{Operation: EditInsert, IndexA: i, IndexB: j})
+		default:
+			i--
+			j--
+			edits = append(edits, Edit{Operation: EditSubstitute, IndexA: i, IndexB: j})
+		}
+	}
+
+	for l, r := 0, len(edits)-1; l < r; l, r = l+1, r-1 {
+		edits[l], edits[r] = edits[r], edits[l]
+	}
+
+	return edits
+}
+
 // CompareWord returns the Levenshtein similarity between wordA and wordB strings.
 // The function is a specialization of Compare for characters.
 func (m *Levenshtein) CompareWord(wordA, wordB string) float64 {
-	return m.Compare(stringToElementSlice(wordA), stringToElementSlice(wordB), DefaultCompareFn())
+	return m.CompareIDs(runeIDs(wordA), runeIDs(wordB))
 }
 
 // DistanceWord returns the Levenshtein distance between wordA and wordB strings.
 // The function is a specialization of Distance for characters.
 func (m *Levenshtein) DistanceWord(wordA, wordB string) int {
-	return m.Distance(stringToElementSlice(wordA), stringToElementSlice(wordB), DefaultCompareFn())
+	return m.DistanceIDs(runeIDs(wordA), runeIDs(wordB))
 }
 
 // CompareSentence returns the Levenshtein similarity between sentenceA and sentenceB sentences.
 // Sentence consists from words. The function is a specialization of Compare for strings with
 // case sensitive strings comparing.
 func (m *Levenshtein) CompareSentence(sentenceA, sentenceB []string) float64 {
-	return m.Compare(stringSliceToElementSlice(sentenceA), stringSliceToElementSlice(sentenceB),
-		DefaultCompareFn())
+	idsA, idsB := internWords(sentenceA, sentenceB)
+
+	return m.CompareIDs(idsA, idsB)
+}
+
+// CompareSentenceBounded returns the Levenshtein similarity between sentenceA and sentenceB sentences and true when
+// it reaches the threshold. The function is a specialization of CompareBounded for strings with case sensitive
+// strings comparing.
+func (m *Levenshtein) CompareSentenceBounded(sentenceA, sentenceB []string, threshold float64) (float64, bool) {
+	lenA, lenB := len(sentenceA), len(sentenceB)
+
+	// Sentences of too different lengths are rejected before words are interned.
+	worst := m.worstDistance(lenA, lenB)
+	minDistance := Max(lenA-lenB, lenB-lenA) * Min(m.InsertCost, m.DeleteCost)
+
+	if worst > 0 && minDistance > maxSimilarDistance(worst, threshold) {
+		return 0, false
+	}
+
+	idsA, idsB := internWords(sentenceA, sentenceB)
+
+	return m.CompareBoundedIDs(idsA, idsB, threshold)
 }
 
 // DistanceSentence returns the Levenshtein distance between sentenceA and sentenceB sentences.
 // Sentence consists from words. The function is a specialization of Distance for strings with
 // case sensitive strings comparing.
 func (m *Levenshtein) DistanceSentence(sentenceA, sentenceB []string) int {
-	return m.Distance(stringSliceToElementSlice(sentenceA), stringSliceToElementSlice(sentenceB),
-		DefaultCompareFn())
+	idsA, idsB := internWords(sentenceA, sentenceB)
+
+	return m.DistanceIDs(idsA, idsB)
+}
+
+// EditScriptSentence returns the edit script turning sentenceA into sentenceB.
+// The function is a specialization of EditScript for strings with case sensitive strings comparing.
+func (m *Levenshtein) EditScriptSentence(sentenceA, sentenceB []string) []Edit {
+	idsA, idsB := internWords(sentenceA, sentenceB)
+
+	return m.EditScriptIDs(idsA, idsB)
+}
+
+// CompareSentenceWeighted returns the weighted Levenshtein similarity between sentenceA and sentenceB sentences.
+// Words are weighed with weight function. The function is a specialization of CompareWeightedIDs for strings with
+// case sensitive strings comparing.
+func (m *Levenshtein) CompareSentenceWeighted(sentenceA, sentenceB []string, weight WeightFn) float64 {
+	idsA, idsB := internWords(sentenceA, sentenceB)
+
+	return m.CompareWeightedIDs(idsA, idsB, tokenWeights(sentenceA, sentenceB, idsA, idsB, weight))
+}
+
+// CompareWeightedIDs returns the weighted Levenshtein similarity of token ids sequences idsA and idsB. weights[id]
+// is the weight of the token id: its insertion costs InsertCost*weight, its deletion DeleteCost*weight and its
+// substitution by another token ReplaceCost multiplied by the larger weight of both tokens. So editing a heavy token
+// costs more than editing a light one.
+//
+// The similarity is 1 - distance/worst, where worst is the weighted distance of the sequences as if they had no equal
+// tokens. Both distances are computed in the same pass.
+func (m *Levenshtein) CompareWeightedIDs(idsA, idsB []uint32, weights []float64) float64 {
+	distance, worst := m.weightedDistances(idsA, idsB, weights)
+	if distance == 0 {
+		return compareSame
+	}
+
+	return compareSame - distance/worst
+}
+
+// WeightedDistanceIDs returns the weighted Levenshtein distance between token ids sequences idsA and idsB like
+// CompareWeightedIDs.
+func (m *Levenshtein) WeightedDistanceIDs(idsA, idsB []uint32, weights []float64) float64 {
+	distance, _ := m.weightedDistances(idsA, idsB, weights)
+
+	return distance
+}
+
+// weightedDistances returns the weighted distance between idsA and idsB and the weighted distance of the sequences
+// as if they had no equal tokens. A transposition costs TransposeCost multiplied by the larger weight of both tokens,
+// sequences without equal tokens have no transpositions.
+func (m *Levenshtein) weightedDistances(idsA, idsB []uint32, weights []float64) (float64, float64) {
+	ins, del, rep := float64(m.InsertCost), float64(m.DeleteCost), float64(m.ReplaceCost)
+	lenB := len(idsB)
+
+	prevCol := make([]float64, lenB+1)
+	for j, b := range idsB {
+		prevCol[j+1] = prevCol[j] + ins*weights[b]
+	}
+
+	prevWorst := make([]float64, lenB+1)
+	copy(prevWorst, prevCol)
+
+	prevPrevCol := make([]float64, lenB+1)
+	col := make([]float64, lenB+1)
+	worst := make([]float64, lenB+1)
+
+	for i, a := range idsA {
+		wa := weights[a]
+		col[0] = prevCol[0] + del*wa
+		worst[0] = col[0]
+
+		for j, b := range idsB {
+			wb := weights[b]
+			sub := rep * math.Max(wa, wb)
+
+			cost := prevCol[j]
+			if a != b {
+				cost += sub
+			}
+
+			col[j+1] = math.Min(cost, math.Min(prevCol[j+1]+del*wa, col[j]+ins*wb))
+
+			if m.transposed(idsA, idsB, i+1, j+1) {
+				col[j+1] = math.Min(col[j+1], prevPrevCol[j-1]+float64(m.TransposeCost)*math.Max(wa, weights[idsA[i-1]]))
+			}
+
+			worst[j+1] = math.Min(prevWorst[j]+sub, math.Min(prevWorst[j+1]+del*wa, worst[j]+ins*wb))
+		}
+
+		prevPrevCol, prevCol, col = prevCol, col, prevPrevCol
+		worst, prevWorst = prevWorst, worst
+	}
+
+	return prevCol[lenB], prevWorst[lenB]
 }
 
-func stringToElementSlice(str string) []Element {
-	res := make([]Element, len(str))
+// transposed reports whether the last two of i elements of idsA are the last two of j elements of idsB swapped,
+// so the cell (i, j) of the matrix continues the cell (i-2, j-2) with a transposition.
+func (m *Levenshtein) transposed(idsA, idsB []uint32, i, j int) bool {
+	return m.TransposeCost > 0 && i > 1 && j > 1 && idsA[i-1] == idsB[j-2] && idsA[i-2] == idsB[j-1] &&
+		idsA[i-1] != idsA[i-2]
+}
+
+// worstDistance returns the distance of sequences of lenA and lenB elements without equal elements. It is the largest
+// distance of sequences of these lengths, transpositions swap equal elements.
+func (m *Levenshtein) worstDistance(lenA, lenB int) int {
+	common := Min(lenA, lenB)
+
+	return common*Min(m.ReplaceCost, m.InsertCost+m.DeleteCost) + (lenA-common)*m.DeleteCost +
+		(lenB-common)*m.InsertCost
+}
+
+// levenshteinScore returns the similarity of sequences at the distance. Sequences without equal elements are
+// at the worst distance.
+func levenshteinScore(distance, worst int) float64 {
+	if distance == 0 {
+		return compareSame
+	}
+
+	return compareSame - float64(distance)/float64(worst)
+}
+
+// maxSimilarDistance returns the largest distance of sequences which similarity reaches the threshold. Sequences
+// without equal elements are at the worst distance. It is negative when even equal sequences do not reach
+// the threshold.
+func maxSimilarDistance(worst int, threshold float64) int {
+	distance := int((compareSame-threshold)*float64(worst)) + 1
+
+	// The distance is adjusted to the exact score computation, so the bound agrees with Compare.
+	for distance >= 0 && levenshteinScore(distance, worst) < threshold {
+		distance--
+	}
+
+	return distance
+}
+
+// internWords returns token ids of words of both sentences. Equal words get equal ids.
+func internWords(sentenceA, sentenceB []string) ([]uint32, []uint32) {
+	dict := make(map[string]uint32, len(sentenceA)+len(sentenceB))
+
+	intern := func(sentence []string) []uint32 {
+		ids := make([]uint32, len(sentence))
+
+		for i, w := range sentence {
+			id, ok := dict[w]
+			if !ok {
+				id = uint32(len(dict))
+				dict[w] = id
+			}
+
+			ids[i] = id
+		}
+
+		return ids
+	}
+
+	return intern(sentenceA), intern(sentenceB)
+}
+
+// tokenWeights returns weights of words of both sentences indexed by their token ids.
+func tokenWeights(sentenceA, sentenceB []string, idsA, idsB []uint32, weight WeightFn) []float64 {
+	weights := make([]float64, len(sentenceA)+len(sentenceB))
+
+	for i, id := range idsA {
+		weights[id] = weight(sentenceA[i])
+	}
+
+	for i, id := range idsB {
+		weights[id] = weight(sentenceB[i])
+	}
+
+	return weights
+}
+
+// internElements returns token ids of elements of both sequences. Elements equal by compare function get equal ids,
+// so compare must be an equivalence relation. Every element is compared with the first element of each id. Without
+// compare function elements are compared with ==, so they are interned through a map.
+func internElements(sequenceA, sequenceB []Element, compare CompareFn) ([]uint32, []uint32) {
+	if compare == nil {
+		return internComparable(sequenceA, sequenceB)
+	}
+
+	representatives := make([]Element, 0)
+
+	intern := func(sequence []Element) []uint32 {
+		ids := make([]uint32, len(sequence))
+
+		for i, e := range sequence {
+			id := len(representatives)
+
+			for r, representative := range representatives {
+				if compare(representative, e) {
+					id = r
+
+					break
+				}
+			}
+
+			if id == len(representatives) {
+				representatives = append(representatives, e)
+			}
+
+			ids[i] = uint32(id)
+		}
+
+		return ids
+	}
+
+	return intern(sequenceA), intern(sequenceB)
+}
+
+// internComparable returns token ids of comparable elements of both sequences. Equal elements get equal ids.
+func internComparable(sequenceA, sequenceB []Element) ([]uint32, []uint32) {
+	dict := make(map[Element]uint32, len(sequenceA)+len(sequenceB))
+
+	intern := func(sequence []Element) []uint32 {
+		ids := make([]uint32, len(sequence))
+
+		for i, e := range sequence {
+			id, ok := dict[e]
+			if !ok {
+				id = uint32(len(dict))
+				dict[e] = id
+			}
+
+			ids[i] = id
+		}
 
-	for i, r := range str {
-		res[i] = r
+		return ids
 	}
 
-	return res
+	return intern(sequenceA), intern(sequenceB)
 }
 
-func stringSliceToElementSlice(slice []string) []Element {
-	res := make([]Element, len(slice))
+// runeIDs returns characters of the word as token ids.
+func runeIDs(word string) []uint32 {
+	ids := make([]uint32, 0, len(word))
 
-	for i := range slice {
-		res[i] = slice[i]
+	for _, r := range word {
+		ids = append(ids, uint32(r))
 	}
 
-	return res
+	return ids
 }
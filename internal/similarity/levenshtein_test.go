@@ -1,6 +1,9 @@
 package similarity
 
 import (
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -70,6 +73,18 @@ func TestLevenshtein_Distance(t *testing.T) {
 	}
 }
 
+func TestLevenshtein_Distance_CompareFn(t *testing.T) {
+	lev := NewLevenshtein()
+	sequenceA := []Element{Element("Hello"), Element("World"), Element("hello")}
+	sequenceB := []Element{Element("hello"), Element("world"), Element("HELLO")}
+	equalFold := func(a, b Element) bool {
+		return strings.EqualFold(a.(string), b.(string))
+	}
+
+	assert.Equal(t, 3, lev.Distance(sequenceA, sequenceB, DefaultCompareFn()))
+	assert.Equal(t, 0, lev.Distance(sequenceA, sequenceB, equalFold))
+}
+
 func TestLevenshtein_CompareWord(t *testing.T) {
 	lev := NewLevenshtein()
 
@@ -95,6 +110,91 @@ func TestLevenshtein_CompareSentence(t *testing.T) {
 	assert.Equal(t, 0.4, res)
 }
 
+func TestLevenshtein_CompareSentence_Costs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		lev       *Levenshtein
+		sentenceA []string
+		sentenceB []string
+		expected  float64
+	}{
+		"when substitution costs as deletion with insertion": {
+			lev:       &Levenshtein{InsertCost: 1, DeleteCost: 1, ReplaceCost: 2},
+			sentenceA: []string{"one", "two"},
+			sentenceB: []string{"one", "three"},
+			expected:  0.5,
+		},
+		"when different sentences and expensive substitution": {
+			lev:       &Levenshtein{InsertCost: 1, DeleteCost: 1, ReplaceCost: 3},
+			sentenceA: []string{"one", "two"},
+			sentenceB: []string{"three", "four"},
+			expected:  0,
+		},
+		"when different sentences and expensive insertion": {
+			lev:       &Levenshtein{InsertCost: 2, DeleteCost: 1, ReplaceCost: 1},
+			sentenceA: []string{"one"},
+			sentenceB: []string{"two", "three"},
+			expected:  0,
+		},
+		"when inserted word and expensive insertion": {
+			lev:       &Levenshtein{InsertCost: 2, DeleteCost: 1, ReplaceCost: 1},
+			sentenceA: []string{"one"},
+			sentenceB: []string{"one", "two"},
+			expected:  1.0 / 3,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			res := tc.lev.CompareSentence(tc.sentenceA, tc.sentenceB)
+
+			assert.InDelta(t, tc.expected, res, 1e-9)
+		})
+	}
+}
+
+func TestLevenshtein_CompareSentenceWeighted(t *testing.T) {
+	weights := map[string]float64{"and": 0.1, "senate": 3}
+	weight := func(word string) float64 {
+		if w, ok := weights[word]; ok {
+			return w
+		}
+
+		return 1
+	}
+
+	lev := NewLevenshtein()
+	sentence := []string{"president", "and", "senate", "agreed"}
+
+	assert.Equal(t, 1.0, lev.CompareSentenceWeighted(sentence, sentence, weight))
+	assert.Equal(t, 0.0, lev.CompareSentenceWeighted(sentence, []string{"nothing", "in", "common"}, weight))
+
+	common := lev.CompareSentenceWeighted(sentence, []string{"president", "senate", "agreed"}, weight)
+	rare := lev.CompareSentenceWeighted(sentence, []string{"president", "and", "house", "agreed"}, weight)
+
+	assert.InDelta(t, 1-0.1/5.1, common, 1e-9)
+	assert.InDelta(t, 1-3.0/5.1, rare, 1e-9)
+}
+
+// TestLevenshtein_CompareSentenceWeighted_UnitWeights checks on random sentences that the weighted similarity with
+// unit weights equals the similarity.
+func TestLevenshtein_CompareSentenceWeighted_UnitWeights(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	unit := func(string) float64 { return 1 }
+
+	for i := 0; i < 1000; i++ {
+		sentenceA := randomWords(rnd, rnd.Intn(12), 4)
+		sentenceB := randomWords(rnd, rnd.Intn(12), 4)
+
+		for _, lev := range []*Levenshtein{
+			NewLevenshtein(), NewDamerauLevenshtein(),
+			{InsertCost: 1, DeleteCost: 2, ReplaceCost: 4, TransposeCost: 3},
+		} {
+			assert.InDelta(t, lev.CompareSentence(sentenceA, sentenceB),
+				lev.CompareSentenceWeighted(sentenceA, sentenceB, unit), 1e-9, "%v %v", sentenceA, sentenceB)
+		}
+	}
+}
+
 func TestLevenshtein_DistanceSentence(t *testing.T) {
 	lev := NewLevenshtein()
 
@@ -103,3 +203,295 @@ func TestLevenshtein_DistanceSentence(t *testing.T) {
 
 	assert.Equal(t, 3, res)
 }
+
+func TestLevenshtein_EditScriptSentence(t *testing.T) {
+	for name, tc := range map[string]struct {
+		sentenceA []string
+		sentenceB []string
+		expected  []Edit
+	}{
+		"when equal sentences": {
+			sentenceA: []string{"one", "two"},
+			sentenceB: []string{"one", "two"},
+			expected:  []Edit{},
+		},
+		"when one empty sentence": {
+			sentenceA: []string{},
+			sentenceB: []string{"one", "two"},
+			expected: []Edit{
+				{Operation: EditInsert, IndexA: 0, IndexB: 0},
+				{Operation: EditInsert, IndexA: 0, IndexB: 1},
+			},
+		},
+		"when different sentences": {
+			sentenceA: []string{"one", "two", "three", "three", "four"},
+			sentenceB: []string{"five", "two", "three", "Three"},
+			expected: []Edit{
+				{Operation: EditSubstitute, IndexA: 0, IndexB: 0},
+				{Operation: EditSubstitute, IndexA: 3, IndexB: 3},
+				{Operation: EditDelete, IndexA: 4, IndexB: 4},
+			},
+		},
+		"when word inserted": {
+			sentenceA: []string{"one", "three"},
+			sentenceB: []string{"one", "two", "three"},
+			expected: []Edit{
+				{Operation: EditInsert, IndexA: 1, IndexB: 1},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			lev := NewLevenshtein()
+
+			res := lev.EditScriptSentence(tc.sentenceA, tc.sentenceB)
+
+			assert.Equal(t, tc.expected, res)
+			assert.Len(t, res, lev.DistanceSentence(tc.sentenceA, tc.sentenceB))
+		})
+	}
+}
+
+func TestLevenshtein_BoundedDistance(t *testing.T) {
+	for name, tc := range map[string]struct {
+		sentenceA        []string
+		sentenceB        []string
+		maxDistance      int
+		expected         int
+		expectedInBounds bool
+	}{
+		"when distance is the bound": {
+			sentenceA:        []string{"one", "two", "three", "three", "four"},
+			sentenceB:        []string{"five", "two", "three", "Three"},
+			maxDistance:      3,
+			expected:         3,
+			expectedInBounds: true,
+		},
+		"when distance exceeds the bound": {
+			sentenceA:        []string{"one", "two", "three", "three", "four"},
+			sentenceB:        []string{"five", "two", "three", "Three"},
+			maxDistance:      2,
+			expected:         0,
+			expectedInBounds: false,
+		},
+		"when lengths differ more than the bound": {
+			sentenceA:        []string{"one", "two", "three", "four"},
+			sentenceB:        []string{"one"},
+			maxDistance:      2,
+			expected:         0,
+			expectedInBounds: false,
+		},
+		"when one empty sentence": {
+			sentenceA:        []string{},
+			sentenceB:        []string{"one", "two"},
+			maxDistance:      2,
+			expected:         2,
+			expectedInBounds: true,
+		},
+		"when negative bound": {
+			sentenceA:        []string{"one"},
+			sentenceB:        []string{"one"},
+			maxDistance:      -1,
+			expected:         0,
+			expectedInBounds: false,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			lev := NewLevenshtein()
+
+			res, ok := lev.BoundedDistance(elements(tc.sentenceA), elements(tc.sentenceB), DefaultCompareFn(),
+				tc.maxDistance)
+
+			assert.Equal(t, tc.expectedInBounds, ok)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+// TestLevenshtein_CompareSentenceBounded_AgreesWithCompare checks on random sentences that the bounded similarity
+// decides the same as the similarity compared with the threshold and that token ids and elements agree.
+func TestLevenshtein_CompareSentenceBounded_AgreesWithCompare(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		sentenceA := randomWords(rnd, rnd.Intn(12), 4)
+		sentenceB := randomWords(rnd, rnd.Intn(12), 4)
+		threshold := float64(rnd.Intn(11)) / 10
+
+		for _, lev := range []*Levenshtein{
+			NewLevenshtein(), NewDamerauLevenshtein(),
+			{InsertCost: 1, DeleteCost: 2, ReplaceCost: 3, TransposeCost: 0},
+			{InsertCost: 1, DeleteCost: 2, ReplaceCost: 3, TransposeCost: 1},
+		} {
+			expected := lev.CompareSentence(sentenceA, sentenceB)
+			assert.Equal(t, expected, lev.Compare(elements(sentenceA), elements(sentenceB), DefaultCompareFn()))
+
+			res, ok := lev.CompareSentenceBounded(sentenceA, sentenceB, threshold)
+
+			assert.Equal(t, expected >= threshold, ok, "%v %v %f", sentenceA, sentenceB, threshold)
+
+			if ok {
+				assert.Equal(t, expected, res)
+			}
+		}
+	}
+}
+
+func TestDamerauLevenshtein_DistanceWord(t *testing.T) {
+	for name, tc := range map[string]struct {
+		wordA    string
+		wordB    string
+		expected int
+	}{
+		"when swapped characters": {
+			wordA:    "ca",
+			wordB:    "ac",
+			expected: 1,
+		},
+		"when swapped characters inside word": {
+			wordA:    "hello",
+			wordB:    "hlelo",
+			expected: 1,
+		},
+		"when swapped characters are edited again": {
+			wordA:    "ca",
+			wordB:    "abc",
+			expected: 3,
+		},
+		"when swapped equal characters": {
+			wordA:    "aa",
+			wordB:    "aa",
+			expected: 0,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			lev := NewDamerauLevenshtein()
+
+			assert.Equal(t, tc.expected, lev.DistanceWord(tc.wordA, tc.wordB))
+		})
+	}
+}
+
+func TestDamerauLevenshtein_DistanceSentence(t *testing.T) {
+	sentenceA := []string{"world", "hello", "from", "kyiv"}
+	sentenceB := []string{"hello", "world", "from", "kyiv"}
+
+	assert.Equal(t, 2, NewLevenshtein().DistanceSentence(sentenceA, sentenceB))
+	assert.Equal(t, 1, NewDamerauLevenshtein().DistanceSentence(sentenceA, sentenceB))
+	assert.Equal(t, 0.75, NewDamerauLevenshtein().CompareSentence(sentenceA, sentenceB))
+	assert.Equal(t, []Edit{{Operation: EditTranspose, IndexA: 0, IndexB: 0}},
+		NewDamerauLevenshtein().EditScriptSentence(sentenceA, sentenceB))
+}
+
+func TestLevenshtein_DistanceWord_Unicode(t *testing.T) {
+	lev := NewLevenshtein()
+
+	assert.Equal(t, 1, lev.DistanceWord("кіт", "кит"))
+}
+
+func BenchmarkLevenshtein_Compare(b *testing.B) {
+	for name, reprint := range benchmarkArticles() {
+		b.Run(name, func(b *testing.B) {
+			original, reprint := elements(benchmarkArticle()), elements(reprint)
+			lev := NewLevenshtein()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				lev.Compare(original, reprint, DefaultCompareFn())
+			}
+		})
+	}
+}
+
+func BenchmarkLevenshtein_CompareSentence(b *testing.B) {
+	for name, reprint := range benchmarkArticles() {
+		b.Run(name, func(b *testing.B) {
+			original := benchmarkArticle()
+			lev := NewLevenshtein()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				lev.CompareSentence(original, reprint)
+			}
+		})
+	}
+}
+
+func BenchmarkLevenshtein_CompareIDs(b *testing.B) {
+	for name, reprint := range benchmarkArticles() {
+		b.Run(name, func(b *testing.B) {
+			original, reprint := internWords(benchmarkArticle(), reprint)
+			lev := NewLevenshtein()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				lev.CompareIDs(original, reprint)
+			}
+		})
+	}
+}
+
+func BenchmarkLevenshtein_CompareSentenceBounded(b *testing.B) {
+	for name, reprint := range benchmarkArticles() {
+		b.Run(name, func(b *testing.B) {
+			original := benchmarkArticle()
+			lev := NewLevenshtein()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				lev.CompareSentenceBounded(original, reprint, 0.95)
+			}
+		})
+	}
+}
+
+const benchmarkArticleWords = 5000
+
+// benchmarkArticle returns the article of 5000 random words.
+func benchmarkArticle() []string {
+	return randomWords(rand.New(rand.NewSource(1)), benchmarkArticleWords, benchmarkArticleWords)
+}
+
+// benchmarkArticles returns articles compared with the benchmark article: a reprint with every 50th word changed,
+// an article of a different length and a different article of the same length.
+func benchmarkArticles() map[string][]string {
+	reprint := benchmarkArticle()
+	for i := 0; i < len(reprint); i += 50 {
+		reprint[i] = "changed"
+	}
+
+	return map[string][]string{
+		"when reprint":          reprint,
+		"when different length": benchmarkArticle()[:benchmarkArticleWords*4/5],
+		"when different":        randomWords(rand.New(rand.NewSource(2)), benchmarkArticleWords, benchmarkArticleWords),
+	}
+}
+
+func randomWords(rnd *rand.Rand, n, vocabulary int) []string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", rnd.Intn(vocabulary))
+	}
+
+	return words
+}
+
+func elements(words []string) []Element {
+	res := make([]Element, len(words))
+	for i, w := range words {
+		res[i] = w
+	}
+
+	return res
+}
@@ -0,0 +1,153 @@
+package similarity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Algorithm is a name of the similarity metric.
+type Algorithm string
+
+const (
+	AlgorithmLevenshtein        Algorithm = "levenshtein"
+	AlgorithmDamerauLevenshtein Algorithm = "damerau_levenshtein"
+	AlgorithmJaccard            Algorithm = "jaccard"
+	AlgorithmCosine             Algorithm = "cosine"
+	AlgorithmSimHash            Algorithm = "simhash"
+	AlgorithmJaroWinkler        Algorithm = "jaro_winkler"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown similarity algorithm")
+	ErrInvalidCost      = errors.New("invalid edit operation cost")
+)
+
+// Metric compares normalized words of two contents.
+type Metric interface {
+	// Compare returns the similarity of wordsA and wordsB. The returned similarity is a number between 0 and 1.
+	// Larger similarity numbers indicate closer matches.
+	Compare(wordsA, wordsB []string) float64
+
+	// DefaultThreshold returns the similarity threshold suitable for the metric when it is not configured.
+	DefaultThreshold() float64
+
+	// Algorithm returns the name of the metric.
+	Algorithm() Algorithm
+}
+
+// BoundedMetric is a metric which decides whether the similarity reaches the threshold faster than it computes
+// the similarity.
+type BoundedMetric interface {
+	Metric
+
+	// CompareBounded returns the similarity of wordsA and wordsB and true when it reaches the threshold. Otherwise
+	// it returns false and the similarity is not computed.
+	CompareBounded(wordsA, wordsB []string, threshold float64) (float64, bool)
+}
+
+// NewMetric returns a new metric by the algorithm name.
+func NewMetric(algorithm Algorithm) (Metric, error) {
+	switch algorithm {
+	case AlgorithmLevenshtein:
+		return NewWordLevenshtein(), nil
+	case AlgorithmDamerauLevenshtein:
+		return NewWordDamerauLevenshtein(), nil
+	case AlgorithmJaccard:
+		return NewJaccard(), nil
+	case AlgorithmCosine:
+		return NewCosine(), nil
+	case AlgorithmSimHash:
+		return NewSimHash(), nil
+	case AlgorithmJaroWinkler:
+		return NewJaroWinkler(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, algorithm)
+	}
+}
+
+// WordLevenshtein represents the Levenshtein metric over words.
+//
+// Threshold semantics: the similarity is 1 - distance/worst, where worst is the distance of contents of the same
+// lengths without equal words. With unit costs worst is max(len), so the threshold 0.95 allows one edited word per
+// twenty words of the longer content. When words are weighted, editing a word costs its weight times the operation
+// cost, so the threshold allows fewer edits of rare words than of common ones.
+type WordLevenshtein struct {
+	lev *Levenshtein
+
+	// weights are weights of words, all words weigh 1 when it is empty.
+	weights Weights
+}
+
+// NewWordLevenshtein returns a new Levenshtein metric over words with unit costs.
+func NewWordLevenshtein() *WordLevenshtein {
+	return &WordLevenshtein{
+		lev:     NewLevenshtein(),
+		weights: Weights{},
+	}
+}
+
+// NewWordDamerauLevenshtein returns a new optimal string alignment metric over words with unit costs, so swapped
+// adjacent words are a single edit.
+func NewWordDamerauLevenshtein() *WordLevenshtein {
+	return &WordLevenshtein{
+		lev:     NewDamerauLevenshtein(),
+		weights: Weights{},
+	}
+}
+
+// NewWeightedWordLevenshtein returns a new Levenshtein metric over words with costs of lev and weights of words.
+// Costs of insertion, deletion and substitution must be positive, transpositions are not edits when their cost
+// is zero.
+func NewWeightedWordLevenshtein(lev *Levenshtein, weights Weights) (*WordLevenshtein, error) {
+	if err := lev.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &WordLevenshtein{
+		lev:     lev,
+		weights: weights,
+	}, nil
+}
+
+// Compare returns the Levenshtein similarity of wordsA and wordsB.
+func (m *WordLevenshtein) Compare(wordsA, wordsB []string) float64 {
+	if m.weights.isEmpty() {
+		return m.lev.CompareSentence(wordsA, wordsB)
+	}
+
+	return m.lev.CompareSentenceWeighted(wordsA, wordsB, m.weights.Weight)
+}
+
+// CompareBounded returns the Levenshtein similarity of wordsA and wordsB when it reaches the threshold. Articles
+// which are not similar are rejected without filling the whole distance matrix. Weighted similarity is computed
+// exactly and compared with the threshold.
+func (m *WordLevenshtein) CompareBounded(wordsA, wordsB []string, threshold float64) (float64, bool) {
+	if m.weights.isEmpty() {
+		return m.lev.CompareSentenceBounded(wordsA, wordsB, threshold)
+	}
+
+	sim := m.Compare(wordsA, wordsB)
+
+	return sim, sim >= threshold
+}
+
+// Levenshtein returns the Levenshtein metric with costs of edit operations of words.
+func (m *WordLevenshtein) Levenshtein() *Levenshtein {
+	return m.lev
+}
+
+// DefaultThreshold returns 0.95.
+func (m *WordLevenshtein) DefaultThreshold() float64 {
+	const threshold = 0.95
+
+	return threshold
+}
+
+// Algorithm returns AlgorithmDamerauLevenshtein when transpositions are edits, otherwise AlgorithmLevenshtein.
+func (m *WordLevenshtein) Algorithm() Algorithm {
+	if m.lev.TransposeCost > 0 {
+		return AlgorithmDamerauLevenshtein
+	}
+
+	return AlgorithmLevenshtein
+}
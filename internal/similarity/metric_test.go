@@ -0,0 +1,53 @@
+package similarity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMetric(t *testing.T) {
+	for _, algorithm := range []Algorithm{AlgorithmLevenshtein, AlgorithmDamerauLevenshtein, AlgorithmJaccard,
+		AlgorithmCosine, AlgorithmSimHash, AlgorithmJaroWinkler} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			metric, err := NewMetric(algorithm)
+
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, metric.Algorithm())
+			assert.InDelta(t, 1.0, metric.Compare([]string{"hello", "world"}, []string{"hello", "world"}), 1e-9)
+			assert.Greater(t, metric.DefaultThreshold(), 0.0)
+			assert.LessOrEqual(t, metric.DefaultThreshold(), 1.0)
+		})
+	}
+}
+
+func TestNewMetric_Unknown(t *testing.T) {
+	_, err := NewMetric("unknown")
+
+	assert.True(t, errors.Is(err, ErrUnknownAlgorithm))
+}
+
+func TestNewWeightedWordLevenshtein_InvalidCost(t *testing.T) {
+	_, err := NewWeightedWordLevenshtein(&Levenshtein{InsertCost: 1, DeleteCost: 0, ReplaceCost: 1}, Weights{})
+
+	assert.True(t, errors.Is(err, ErrInvalidCost))
+}
+
+func TestWordLevenshtein_CompareBounded_Weighted(t *testing.T) {
+	weights := Weights{}
+	require.NoError(t, weights.Read(strings.NewReader("and,0.1\nsenate,3")))
+
+	metric, err := NewWeightedWordLevenshtein(NewLevenshtein(), weights)
+	require.NoError(t, err)
+
+	sentence := []string{"president", "and", "senate", "agreed"}
+
+	_, ok := metric.CompareBounded(sentence, []string{"president", "senate", "agreed"}, 0.8)
+	assert.True(t, ok)
+
+	_, ok = metric.CompareBounded(sentence, []string{"president", "and", "house", "agreed"}, 0.8)
+	assert.False(t, ok)
+}
@@ -0,0 +1,210 @@
+package similarity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Stage is a name of the text normalization stage.
+type Stage string
+
+const (
+	// StageNFKC replaces compatibility characters by their equivalents, e.g. ligatures and full-width forms.
+	StageNFKC Stage = "nfkc"
+	// StageLetters removes characters except Unicode letters, numbers and whitespaces.
+	StageLetters Stage = "letters"
+	// StageDiacritics removes diacritical marks: "café" becomes "cafe".
+	StageDiacritics Stage = "diacritics"
+	// StageCase folds case fully: "Straße" and "STRASSE" both become "strasse".
+	StageCase Stage = "case"
+)
+
+var ErrUnknownStage = errors.New("unknown normalization stage")
+
+// Normalizer is a rune-based text normalization pipeline. Enabled stages run in the order of the fields,
+// then text is split to words and words are processed by the profile of the detected language: stop words are
+// removed and the rest are stemmed. Irregular verbs of English words are replaced by infinitives, English words are
+// stemmed only if StemEnglish is set.
+type Normalizer struct {
+	NFKC              bool
+	FoldCase          bool
+	LettersAndNumbers bool
+	FoldDiacritics    bool
+
+	StemEnglish bool
+	Languages   []*Language
+	Irregular   IrregularVerb
+}
+
+// DefaultNormalizer returns the normalizer with all stages enabled which detects all supported languages.
+// English words are not stemmed.
+func DefaultNormalizer(irregular IrregularVerb) Normalizer {
+	n := EnglishNormalizer(irregular)
+	n.Languages = Languages()
+
+	return n
+}
+
+// EnglishNormalizer returns the normalizer with all stages enabled which removes English stop words
+// and replaces irregular verbs by infinitives. English words are not stemmed.
+func EnglishNormalizer(irregular IrregularVerb) Normalizer {
+	return Normalizer{
+		NFKC:              true,
+		FoldCase:          true,
+		LettersAndNumbers: true,
+		FoldDiacritics:    true,
+		StemEnglish:       false,
+		Languages:         []*Language{English()},
+		Irregular:         irregular,
+	}
+}
+
+// WithStages returns the normalizer with only the given stages enabled.
+func (n Normalizer) WithStages(stages []Stage) (Normalizer, error) {
+	n.NFKC, n.LettersAndNumbers, n.FoldDiacritics, n.FoldCase = false, false, false, false
+
+	for _, stage := range stages {
+		switch stage {
+		case StageNFKC:
+			n.NFKC = true
+		case StageLetters:
+			n.LettersAndNumbers = true
+		case StageDiacritics:
+			n.FoldDiacritics = true
+		case StageCase:
+			n.FoldCase = true
+		default:
+			return Normalizer{}, fmt.Errorf("%w: %s", ErrUnknownStage, stage)
+		}
+	}
+
+	return n, nil
+}
+
+// Normalize runs the enabled stages over s.
+func (n Normalizer) Normalize(s string) string {
+	s = n.normalize(s)
+
+	if n.FoldDiacritics {
+		s = foldDiacritics(s)
+	}
+
+	return s
+}
+
+// Language returns the detected language of the content or nil when it is undetermined.
+func (n Normalizer) Language(content string) *Language {
+	return DetectLanguage(content, n.Languages)
+}
+
+// Words normalizes content and returns its words processed by the profile of the content language.
+func (n Normalizer) Words(content string) []string {
+	return n.words(content, n.Language(content))
+}
+
+// words normalizes content and returns its words processed by the profile of the language.
+func (n Normalizer) words(content string, lang *Language) []string {
+	fields := splitWords(n.normalize(content))
+
+	res := make([]string, 0, len(fields))
+
+	for _, t := range fields {
+		if lang.isStopWord(t) {
+			continue
+		}
+
+		if lang.code() == LanguageEnglish {
+			t = n.Irregular.ToInfinitive(t)
+		}
+
+		if lang.code() != LanguageEnglish || n.StemEnglish {
+			t = lang.stem(t)
+		}
+
+		// Diacritics are folded the last as stop words and stemmers rely on them.
+		if n.FoldDiacritics {
+			t = foldDiacritics(t)
+		}
+
+		res = append(res, t)
+	}
+
+	return res
+}
+
+// normalize runs the enabled stages except diacritic folding over s.
+func (n Normalizer) normalize(s string) string {
+	if n.NFKC {
+		s = norm.NFKC.String(s)
+	}
+
+	if n.FoldCase {
+		// Caser keeps state, so it must not be shared between goroutines.
+		s = cases.Fold().String(s)
+	}
+
+	if n.LettersAndNumbers {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r) || unicode.IsSpace(r) {
+				return r
+			}
+
+			return -1
+		}, s)
+	}
+
+	return s
+}
+
+// foldDiacritics decomposes s, removes nonspacing marks and composes the rest back.
+func foldDiacritics(s string) string {
+	s = norm.NFD.String(s)
+	s = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+
+		return r
+	}, s)
+
+	return norm.NFC.String(s)
+}
+
+// splitWords splits s by whitespace characters. Chinese and Japanese texts do not separate words with spaces,
+// so every Han, Hiragana and Katakana character is a word.
+func splitWords(s string) []string {
+	var (
+		words []string
+		start = -1
+	)
+
+	for i, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			if start >= 0 {
+				words = append(words, s[start:i])
+				start = -1
+			}
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			if start >= 0 {
+				words = append(words, s[start:i])
+				start = -1
+			}
+
+			words = append(words, string(r))
+		case start < 0:
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, s[start:])
+	}
+
+	return words
+}
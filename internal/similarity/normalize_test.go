@@ -0,0 +1,104 @@
+package similarity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	for name, tc := range map[string]struct {
+		stages   []Stage
+		s        string
+		expected string
+	}{
+		"when no stages": {
+			stages:   nil,
+			s:        "Ｃafé, ﬁne!",
+			expected: "Ｃafé, ﬁne!",
+		},
+		"when nfkc": {
+			stages:   []Stage{StageNFKC},
+			s:        "Ｃafé ﬁne",
+			expected: "Café fine",
+		},
+		"when letters": {
+			stages:   []Stage{StageLetters},
+			s:        "hello, world! don't 42",
+			expected: "hello world dont 42",
+		},
+		"when letters keep non-latin": {
+			stages:   []Stage{StageLetters},
+			s:        "Привет, мир! 你好。",
+			expected: "Привет мир 你好",
+		},
+		"when diacritics": {
+			stages:   []Stage{StageDiacritics},
+			s:        "Café naïve Ёлка",
+			expected: "Cafe naive Елка",
+		},
+		"when case": {
+			stages:   []Stage{StageCase},
+			s:        "Straße ΣΊΣΥΦΟΣ Привет",
+			expected: "strasse σίσυφοσ привет",
+		},
+		"when all stages": {
+			stages:   []Stage{StageNFKC, StageLetters, StageDiacritics, StageCase},
+			s:        "Ｃafé, STRAẞE; ﬁne!",
+			expected: "cafe strasse fine",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			n, err := Normalizer{}.WithStages(tc.stages)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, n.Normalize(tc.s))
+		})
+	}
+}
+
+func TestNormalizer_WithStages_Unknown(t *testing.T) {
+	_, err := Normalizer{}.WithStages([]Stage{StageNFKC, "stem"})
+
+	assert.True(t, errors.Is(err, ErrUnknownStage))
+}
+
+func TestNormalizer_Words(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content  string
+		expected []string
+	}{
+		"when english": {
+			content:  "The Hello, a world!",
+			expected: []string{"hello", "world"},
+		},
+		"when cyrillic": {
+			content:  "Привет, МИР!",
+			expected: []string{"привет", "мир"},
+		},
+		"when accented": {
+			content:  "Crème brûlée à la CAFÉ",
+			expected: []string{"creme", "brulee", "a", "la", "cafe"},
+		},
+		"when chinese": {
+			content:  "我爱北京。",
+			expected: []string{"我", "爱", "北", "京"},
+		},
+		"when japanese mixed with latin": {
+			content:  "東京はTokyo",
+			expected: []string{"東", "京", "は", "tokyo"},
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			n := EnglishNormalizer(IrregularVerb{})
+
+			assert.Equal(t, tc.expected, n.Words(tc.content))
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package similarity
+
+// porterShortWord is the length of words which the Porter stemmer leaves as is.
+const porterShortWord = 2
+
+// PorterStemmer is the Porter stemmer of English words.
+type PorterStemmer struct{}
+
+// Stem returns the stem of the lower-cased English word.
+func (PorterStemmer) Stem(word string) string {
+	w := []rune(word)
+	if len(w) <= porterShortWord {
+		return word
+	}
+
+	w = porterStep1a(w)
+	w = porterStep1b(w)
+
+	if hasSuffix(w, "y") && porterHasVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+
+	w = porterReplace(w, [][2]string{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+		{"abli", "able"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"},
+		{"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+		{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	})
+	w = porterReplace(w, [][2]string{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	})
+	w = porterStep4(w)
+	w = porterStep5(w)
+
+	return string(w)
+}
+
+// porterStep1a removes plurals.
+func porterStep1a(w []rune) []rune {
+	switch {
+	case hasSuffix(w, "sses"), hasSuffix(w, "ies"):
+		return w[:suffixStart(w, "es")]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	default:
+		return w
+	}
+}
+
+// porterStep1b removes past participles ed and ing.
+func porterStep1b(w []rune) []rune {
+	if hasSuffix(w, "eed") {
+		if porterMeasure(w[:suffixStart(w, "eed")]) > 0 {
+			return w[:len(w)-1]
+		}
+
+		return w
+	}
+
+	suffix := longestSuffix(w, []string{"ed", "ing"})
+	if suffix == "" || !porterHasVowel(w[:suffixStart(w, suffix)]) {
+		return w
+	}
+
+	w = w[:suffixStart(w, suffix)]
+
+	switch {
+	case hasSuffix(w, "at"), hasSuffix(w, "bl"), hasSuffix(w, "iz"):
+		return append(w, 'e')
+	case porterDoubleConsonant(w) && !isRuneOf(w[len(w)-1], "lsz"):
+		return w[:len(w)-1]
+	case porterMeasure(w) == 1 && porterCVC(w):
+		return append(w, 'e')
+	default:
+		return w
+	}
+}
+
+// porterReplace replaces the longest of the suffixes by its replacement when the stem measure is positive.
+func porterReplace(w []rune, replacements [][2]string) []rune {
+	suffixes := make([]string, 0, len(replacements))
+	for _, r := range replacements {
+		suffixes = append(suffixes, r[0])
+	}
+
+	suffix := longestSuffix(w, suffixes)
+	if suffix == "" || porterMeasure(w[:suffixStart(w, suffix)]) == 0 {
+		return w
+	}
+
+	for _, r := range replacements {
+		if r[0] == suffix {
+			return append(w[:suffixStart(w, suffix)], []rune(r[1])...)
+		}
+	}
+
+	return w
+}
+
+// porterStep4 removes suffixes when the stem measure is greater than one.
+func porterStep4(w []rune) []rune {
+	suffix := longestSuffix(w, []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment", "ent", "ion", "ou", "ism", "ate",
+		"iti", "ous", "ive", "ize",
+	})
+	if suffix == "" {
+		return w
+	}
+
+	stem := w[:suffixStart(w, suffix)]
+	if porterMeasure(stem) <= 1 {
+		return w
+	}
+
+	if suffix == "ion" && !hasSuffix(stem, "s") && !hasSuffix(stem, "t") {
+		return w
+	}
+
+	return stem
+}
+
+// porterStep5 removes final e and undoubles final l.
+func porterStep5(w []rune) []rune {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := porterMeasure(stem); m > 1 || m == 1 && !porterCVC(stem) {
+			w = stem
+		}
+	}
+
+	if porterMeasure(w) > 1 && porterDoubleConsonant(w) && hasSuffix(w, "l") {
+		w = w[:len(w)-1]
+	}
+
+	return w
+}
+
+// porterConsonant reports whether the i-th letter of the word is a consonant. Y is a consonant when it follows
+// a vowel or starts the word.
+func porterConsonant(w []rune, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !porterConsonant(w, i-1)
+	default:
+		return true
+	}
+}
+
+// porterMeasure returns the number of vowel-consonant sequences of the stem.
+func porterMeasure(stem []rune) int {
+	m := 0
+	i := 0
+
+	for i < len(stem) && porterConsonant(stem, i) {
+		i++
+	}
+
+	for i < len(stem) {
+		for i < len(stem) && !porterConsonant(stem, i) {
+			i++
+		}
+
+		if i == len(stem) {
+			break
+		}
+
+		for i < len(stem) && porterConsonant(stem, i) {
+			i++
+		}
+
+		m++
+	}
+
+	return m
+}
+
+// porterHasVowel reports whether the stem contains a vowel.
+func porterHasVowel(stem []rune) bool {
+	for i := range stem {
+		if !porterConsonant(stem, i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// porterDoubleConsonant reports whether the stem ends with a double consonant.
+func porterDoubleConsonant(stem []rune) bool {
+	n := len(stem)
+
+	return n > 1 && stem[n-1] == stem[n-2] && porterConsonant(stem, n-1)
+}
+
+// porterCVC reports whether the stem ends with consonant-vowel-consonant and the last consonant is not w, x or y.
+func porterCVC(stem []rune) bool {
+	pattern := []bool{true, false, true}
+
+	start := len(stem) - len(pattern)
+	if start < 0 || isRuneOf(stem[len(stem)-1], "wxy") {
+		return false
+	}
+
+	for i, consonant := range pattern {
+		if porterConsonant(stem, start+i) != consonant {
+			return false
+		}
+	}
+
+	return true
+}
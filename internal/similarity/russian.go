@@ -0,0 +1,145 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Russian returns the profile of Russian language.
+func Russian() *Language {
+	return newLanguage(LanguageRussian, unicode.Cyrillic, russianSample, russianStopWords, RussianStemmer{})
+}
+
+// RussianStemmer is the Snowball Russian stemmer.
+type RussianStemmer struct{}
+
+// Stem returns the stem of the lower-cased Russian word.
+func (RussianStemmer) Stem(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ё", "е"))
+	rv := regionAfterVowel(w, isRussianVowel)
+	r2 := region(w, region(w, 0, isRussianVowel), isRussianVowel) - rv
+
+	prefix, w := w[:rv], w[rv:]
+
+	if s, ok := removeEnding(w, "ая", []string{"в", "вши", "вшись"},
+		[]string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}); ok {
+		w = s
+	} else {
+		if s, ok := removeEnding(w, "", nil, []string{"ся", "сь"}); ok {
+			w = s
+		}
+
+		w = russianRemoveInflection(w)
+	}
+
+	if hasSuffix(w, "и") {
+		w = w[:len(w)-1]
+	}
+
+	if s := longestSuffix(w, []string{"ост", "ость"}); s != "" && suffixStart(w, s) >= r2 {
+		w = w[:suffixStart(w, s)]
+	}
+
+	switch {
+	case hasSuffix(w, "нн"):
+		w = w[:len(w)-1]
+	case hasSuffix(w, "ь"):
+		w = w[:len(w)-1]
+	default:
+		if s, ok := removeEnding(w, "", nil, []string{"ейш", "ейше"}); ok {
+			w = s
+			if hasSuffix(w, "нн") {
+				w = w[:len(w)-1]
+			}
+		}
+	}
+
+	return string(prefix) + string(w)
+}
+
+// russianRemoveInflection removes an adjectival, a verb or a noun ending, whichever is found first.
+func russianRemoveInflection(w []rune) []rune {
+	if s, ok := removeEnding(w, "", nil, []string{
+		"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом", "его", "ого", "ему",
+		"ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}); ok {
+		if p, ok := removeEnding(s, "ая", []string{"ем", "нн", "вш", "ющ", "щ"}, []string{"ивш", "ывш", "ующ"}); ok {
+			return p
+		}
+
+		return s
+	}
+
+	if s, ok := removeEnding(w, "ая", []string{
+		"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно",
+	}, []string{
+		"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ило",
+		"ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю",
+	}); ok {
+		return s
+	}
+
+	s, _ := removeEnding(w, "", nil, []string{
+		"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й", "иям",
+		"ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я",
+	})
+
+	return s
+}
+
+// removeEnding removes the longest of the endings from the word. The preceded endings are removed only
+// when they follow one of the preceding runes which is kept. It reports whether an ending is removed.
+func removeEnding(w []rune, preceding string, preceded, endings []string) ([]rune, bool) {
+	suffix := longestSuffix(w, append(append([]string{}, preceded...), endings...))
+	if suffix == "" {
+		return w, false
+	}
+
+	start := suffixStart(w, suffix)
+
+	if !Contains(endings, suffix) && (start == 0 || !isRuneOf(w[start-1], preceding)) {
+		return w, false
+	}
+
+	return w[:start], true
+}
+
+// regionAfterVowel returns the index after the first vowel of the word, RV of Snowball stemmers.
+func regionAfterVowel(w []rune, isVowel func(r rune) bool) int {
+	for i, r := range w {
+		if isVowel(r) {
+			return i + 1
+		}
+	}
+
+	return len(w)
+}
+
+func isRussianVowel(r rune) bool {
+	return isRuneOf(r, "аеиоуыэюя")
+}
+
+const russianStopWords = `
+и в во не что он на я с со как а то все она так его но да ты к у же вы за бы по только ее мне было вот от меня
+еще нет о из ему теперь когда даже ну вдруг ли если уже или ни быть был него до вас нибудь опять уж вам ведь там
+потом себя ничего ей может они тут где есть надо ней для мы тебя их чем была сам чтоб без будто чего раз тоже
+себе под будет ж тогда кто этот того потому этого какой совсем ним здесь этом один почти мой тем чтобы нее
+сейчас были куда зачем всех никогда можно при наконец два об другой хоть после над больше тот через эти нас про
+всего них какая много разве три эту моя впрочем хорошо свою этой перед иногда лучше чуть том нельзя такой им
+более всегда конечно всю между её ещё
+`
+
+const russianSample = `
+Информационные агентства каждый день публикуют тысячи статей, и многие из них перепечатываются другими
+изданиями с небольшими изменениями. Сервис хранит каждую статью и находит те, у которых почти такое же
+содержание. Когда журналист пишет новую историю о погоде, экономике или правительстве, текст сравнивается с
+историями, которые были опубликованы раньше. Если слова обоих текстов почти совпадают, новая статья отмечается
+как дубликат и попадает в группу первоначальной. Читатели хотят видеть первоисточник, а не копии. Поэтому порядок
+статей важен: самая старая статья группы считается уникальной. Городской совет сообщил в понедельник, что новый
+мост откроют для движения в следующем году. Цены на продукты и энергию снова выросли, и ожидается, что
+центральный банк повысит процентные ставки. Учёные выяснили, что дети, которые проводят больше времени на улице,
+здоровее и счастливее тех, кто остаётся дома. Футбольная команда выиграла матч после долгого и трудного сезона,
+и тысячи болельщиков праздновали на улицах столицы. Хотя погода была холодной, они пошли гулять по парку и
+говорили о том, что видели во время путешествия. Нет ничего нового под солнцем, но каждый писатель верит, что его
+слова стоит прочитать.
+`
@@ -0,0 +1,70 @@
+package similarity
+
+import (
+	"hash/fnv"
+	"math/bits"
+)
+
+const simHashBits = 64
+
+// SimHash represents the SimHash fingerprint metric.
+// For more information see https://en.wikipedia.org/wiki/SimHash.
+//
+// Every content is reduced to a 64-bit fingerprint where words vote for bits weighted by their frequency.
+// Threshold semantics: the similarity is 1 - hamming/64 where hamming is the number of different fingerprint bits.
+// The threshold 0.9 allows up to 6 different bits. The metric is the fastest one, but it is imprecise for short
+// contents.
+type SimHash struct{}
+
+// NewSimHash returns a new SimHash metric.
+func NewSimHash() *SimHash {
+	return &SimHash{}
+}
+
+// Compare returns the similarity of SimHash fingerprints of wordsA and wordsB.
+func (m *SimHash) Compare(wordsA, wordsB []string) float64 {
+	distance := bits.OnesCount64(m.Fingerprint(wordsA) ^ m.Fingerprint(wordsB))
+
+	return compareSame - float64(distance)/simHashBits
+}
+
+// DefaultThreshold returns 0.9.
+func (m *SimHash) DefaultThreshold() float64 {
+	const threshold = 0.9
+
+	return threshold
+}
+
+// Algorithm returns AlgorithmSimHash.
+func (m *SimHash) Algorithm() Algorithm {
+	return AlgorithmSimHash
+}
+
+// Fingerprint returns the SimHash fingerprint of words.
+func (m *SimHash) Fingerprint(words []string) uint64 {
+	var vector [simHashBits]int
+
+	for _, word := range words {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(word))
+		sum := h.Sum64()
+
+		for i := 0; i < simHashBits; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				vector[i]++
+			} else {
+				vector[i]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+
+	for i, v := range vector {
+		if v > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+
+	return fingerprint
+}
@@ -0,0 +1,50 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimHash_Compare(t *testing.T) {
+	for name, tc := range map[string]struct {
+		wordsA   []string
+		wordsB   []string
+		expected float64
+	}{
+		"when empty words": {
+			wordsA:   []string{},
+			wordsB:   []string{},
+			expected: 1.0,
+		},
+		"when reordered words": {
+			wordsA:   []string{"hello", "beautiful", "world"},
+			wordsB:   []string{"world", "hello", "beautiful"},
+			expected: 1.0,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sh := NewSimHash()
+
+			res := sh.Compare(tc.wordsA, tc.wordsB)
+
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestSimHash_Compare_SimilarCloserThanDifferent(t *testing.T) {
+	sh := NewSimHash()
+	words := []string{"the", "quick", "brown", "fox", "jumps", "over", "the", "lazy", "dog", "and", "runs", "away"}
+	similar := []string{"the", "quick", "brown", "fox", "jumps", "over", "the", "lazy", "cat", "and", "runs", "away"}
+	different := []string{"stock", "markets", "fell", "sharply", "after", "the", "central", "bank", "raised", "rates"}
+
+	assert.Greater(t, sh.Compare(words, similar), sh.Compare(words, different))
+}
+
+func TestSimHash_Fingerprint(t *testing.T) {
+	sh := NewSimHash()
+
+	assert.Equal(t, uint64(0), sh.Fingerprint(nil))
+	assert.Equal(t, sh.Fingerprint([]string{"hello", "world"}), sh.Fingerprint([]string{"world", "hello"}))
+}
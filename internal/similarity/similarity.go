@@ -2,65 +2,156 @@ package similarity
 
 import (
 	"log"
-	"strings"
+	"math"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
 )
 
 type Similarity struct {
 	threshold float64
+	// crossThreshold is the threshold of contents of different languages.
+	crossThreshold float64
+
+	normalizer Normalizer
 
-	irregular IrregularVerb
+	metric Metric
 }
 
-func NewSimilarity(threshold float64, irregular IrregularVerb) *Similarity {
+func NewSimilarity(threshold, crossLanguageThreshold float64, normalizer Normalizer, metric Metric) *Similarity {
 	return &Similarity{
-		threshold: threshold,
-		irregular: irregular,
+		threshold:      threshold,
+		crossThreshold: crossLanguageThreshold,
+		normalizer:     normalizer,
+		metric:         metric,
 	}
 }
 
 func (s *Similarity) IsSimilar(idA int, contentA string, idB int, contentB string) bool {
-	log.Printf("use similarity threshold: %f to compare %d and %d", s.threshold, idA, idB)
+	tokensA := s.Tokenize(contentA)
+	tokensB := s.Tokenize(contentB)
 
-	sim := s.Similarity(idA, contentA, idB, contentB) >= s.threshold
+	log.Printf("use similarity threshold: %f to compare %d and %d",
+		s.Threshold(tokensA.Language, tokensB.Language), idA, idB)
+
+	_, sim := s.IsDuplicate(tokensA, tokensB)
 
 	return sim
 }
 
-func (s *Similarity) Similarity(idA int, contentA string, idB int, contentB string) float64 {
-	lev := NewLevenshtein()
+// IsDuplicate returns the similarity of tokenized contents and whether it reaches the threshold of their languages.
+// Bounded metrics stop as soon as the threshold is unreachable, then the returned similarity is zero.
+func (s *Similarity) IsDuplicate(tokensA, tokensB articlesim.Tokens) (float64, bool) {
+	threshold := s.Threshold(tokensA.Language, tokensB.Language)
+
+	if metric, ok := s.metric.(BoundedMetric); ok {
+		return metric.CompareBounded(tokensA.Words, tokensB.Words, threshold)
+	}
+
+	sim := s.Compare(tokensA, tokensB)
+
+	return sim, sim >= threshold
+}
+
+// Algorithm returns the name of the configured metric.
+func (s *Similarity) Algorithm() string {
+	return string(s.metric.Algorithm())
+}
+
+// Threshold returns the similarity from which contents are duplicates. Contents of different languages
+// are compared with the cross-language threshold.
+func (s *Similarity) Threshold(languageA, languageB string) float64 {
+	if languageA != languageB {
+		return s.crossThreshold
+	}
+
+	return s.threshold
+}
 
+// MinThreshold returns the lowest of the thresholds of contents of the same and different languages.
+func (s *Similarity) MinThreshold() float64 {
+	return math.Min(s.threshold, s.crossThreshold)
+}
+
+func (s *Similarity) Similarity(idA int, contentA string, idB int, contentB string) float64 {
 	log.Printf("normalizing %d", idA)
 
-	normA := s.normalizeAndReturnWords(contentA)
+	tokensA := s.Tokenize(contentA)
 
 	log.Printf("normalizing %d", idB)
 
-	normB := s.normalizeAndReturnWords(contentB)
+	tokensB := s.Tokenize(contentB)
 
-	sim := lev.CompareSentence(normA, normB)
+	return s.Compare(tokensA, tokensB)
+}
 
-	return sim
+// Tokenize normalizes the content into words and detects its language. Tokens are compared by Compare without
+// normalizing the content again.
+func (s *Similarity) Tokenize(content string) articlesim.Tokens {
+	lang := s.normalizer.Language(content)
+
+	return articlesim.Tokens{
+		Words:    s.normalizer.words(content, lang),
+		Language: lang.code(),
+	}
 }
 
-// normalizeAndReturnWords removes non-alphanumeric character, splits by whitespace characters,
-// removes articles (a, an, the), change verbs to infinitives and returns lowercase words.
-func (s *Similarity) normalizeAndReturnWords(content string) []string {
-	modContent := string(Strip([]byte(content)))
-	modContent = strings.ToLower(modContent)
-	fields := strings.Fields(modContent)
+// Compare returns the similarity of the configured metric of tokenized contents.
+func (s *Similarity) Compare(tokensA, tokensB articlesim.Tokens) float64 {
+	return s.metric.Compare(tokensA.Words, tokensB.Words)
+}
 
-	res := make([]string, 0, len(fields))
-	articles := []string{"a", "an", "the"}
+// Explain compares tokenized contents like Compare and returns the details of the comparison: detected languages,
+// normalized words, the word-level Levenshtein distance and edit script besides the similarity of the configured
+// metric. The distance and the edit script count edits with the costs of the configured Levenshtein metric, unit
+// costs for other metrics, and ignore word weights. The Levenshtein score is weighted like the configured metric.
+func (s *Similarity) Explain(tokensA, tokensB articlesim.Tokens) articlesim.Comparison {
+	wordsA, wordsB := tokensA.Words, tokensB.Words
 
-	for _, t := range fields {
-		if Contains(articles, t) {
-			continue
+	wordLev, ok := s.metric.(*WordLevenshtein)
+	if !ok {
+		wordLev = NewWordLevenshtein()
+	}
+
+	lev := wordLev.Levenshtein()
+	edits := lev.EditScriptSentence(wordsA, wordsB)
+
+	script := make([]articlesim.EditOp, 0, len(edits))
+
+	for _, e := range edits {
+		op := articlesim.EditOp{
+			Operation:     string(e.Operation),
+			Position:      e.IndexA,
+			OtherPosition: e.IndexB,
+			Word:          "",
+			OtherWord:     "",
 		}
 
-		verb := s.irregular.ToInfinitive(t)
+		if e.Operation != EditInsert {
+			op.Word = wordsA[e.IndexA]
+		}
 
-		res = append(res, verb)
+		if e.Operation != EditDelete {
+			op.OtherWord = wordsB[e.IndexB]
+		}
+
+		script = append(script, op)
 	}
 
-	return res
+	sim := s.Compare(tokensA, tokensB)
+	threshold := s.Threshold(tokensA.Language, tokensB.Language)
+
+	return articlesim.Comparison{
+		ArticleID:        0,
+		OtherArticleID:   0,
+		Language:         tokensA.Language,
+		OtherLanguage:    tokensB.Language,
+		Words:            wordsA,
+		OtherWords:       wordsB,
+		Distance:         lev.DistanceSentence(wordsA, wordsB),
+		LevenshteinScore: wordLev.Compare(wordsA, wordsB),
+		Similarity:       sim,
+		Threshold:        threshold,
+		IsSimilar:        sim >= threshold,
+		EditScript:       script,
+	}
 }
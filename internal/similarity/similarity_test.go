@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	articlesim "github.com/devchallenge/article-similarity/internal"
 )
 
 func TestSimilarity_Similarity(t *testing.T) {
@@ -56,9 +59,16 @@ func TestSimilarity_Similarity(t *testing.T) {
 			contentB: "hello a world,",
 			expected: 1.0,
 		},
+		"when different cyrillic contents": {
+			idA:      1,
+			contentA: "привет мир",
+			idB:      2,
+			contentB: "пока друзья",
+			expected: 0.0,
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
-			sim := NewSimilarity(0.95, IrregularVerb{})
+			sim := NewSimilarity(0.95, 1, EnglishNormalizer(IrregularVerb{}), NewWordLevenshtein())
 
 			res := sim.Similarity(tc.idA, tc.contentA, tc.idB, tc.contentB)
 
@@ -68,9 +78,147 @@ func TestSimilarity_Similarity(t *testing.T) {
 }
 
 func TestSimilarity_IsSimilar(t *testing.T) {
-	sim := NewSimilarity(0.7, IrregularVerb{})
+	sim := NewSimilarity(0.7, 1, EnglishNormalizer(IrregularVerb{}), NewWordLevenshtein())
 
 	res := sim.IsSimilar(1, "hello a very beautiful world", 2, "hello beautiful world")
 
 	assert.True(t, res)
 }
+
+func TestSimilarity_IsDuplicate(t *testing.T) {
+	for name, metric := range map[string]Metric{
+		"when bounded metric": NewWordLevenshtein(),
+		"when metric":         NewJaccard(),
+	} {
+		metric := metric
+		t.Run(name, func(t *testing.T) {
+			sim := NewSimilarity(0.7, 1, EnglishNormalizer(IrregularVerb{}), metric)
+			tokens := sim.Tokenize("hello beautiful new world")
+
+			score, ok := sim.IsDuplicate(tokens, tokens)
+			assert.True(t, ok)
+			assert.Equal(t, 1.0, score)
+
+			_, ok = sim.IsDuplicate(tokens, sim.Tokenize("goodbye cruel old world"))
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestSimilarity_Explain(t *testing.T) {
+	sim := NewSimilarity(0.7, 1, EnglishNormalizer(IrregularVerb{}), NewWordLevenshtein())
+
+	res := sim.Explain(sim.Tokenize("Hello a really beautiful world!"), sim.Tokenize("hello, the beautiful new world"))
+
+	assert.Equal(t, articlesim.Comparison{
+		ArticleID:        0,
+		OtherArticleID:   0,
+		Language:         LanguageEnglish,
+		OtherLanguage:    LanguageEnglish,
+		Words:            []string{"hello", "really", "beautiful", "world"},
+		OtherWords:       []string{"hello", "beautiful", "new", "world"},
+		Distance:         2,
+		LevenshteinScore: 0.5,
+		Similarity:       0.5,
+		Threshold:        0.7,
+		IsSimilar:        false,
+		EditScript: []articlesim.EditOp{
+			{Operation: "delete", Position: 1, OtherPosition: 1, Word: "really", OtherWord: ""},
+			{Operation: "insert", Position: 3, OtherPosition: 2, Word: "", OtherWord: "new"},
+		},
+	}, res)
+}
+
+func TestSimilarity_Threshold(t *testing.T) {
+	sim := NewSimilarity(0.7, 0.9, DefaultNormalizer(IrregularVerb{}), NewWordLevenshtein())
+
+	ru := sim.Tokenize("Кот сидит на окне").Language
+	otherRU := sim.Tokenize("Кот сидит на крыше").Language
+	uk := sim.Tokenize("Кіт сидить на вікні").Language
+
+	assert.Equal(t, 0.7, sim.Threshold(ru, otherRU))
+	assert.Equal(t, 0.9, sim.Threshold(ru, uk))
+}
+
+func TestSimilarity_Tokenize(t *testing.T) {
+	sim := NewSimilarity(0.7, 1, DefaultNormalizer(IrregularVerb{}), NewWordLevenshtein())
+
+	tokens := sim.Tokenize("Hello a really beautiful world!")
+
+	assert.Equal(t, articlesim.Tokens{
+		Words:    []string{"hello", "really", "beautiful", "world"},
+		Language: LanguageEnglish,
+	}, tokens)
+	assert.Equal(t, sim.Similarity(1, "Hello a really beautiful world!", 2, "hello, the beautiful new world"),
+		sim.Compare(tokens, sim.Tokenize("hello, the beautiful new world")))
+}
+
+// TestSimilarity_Similarity_Stemming compares reprints paraphrased by word inflections with and without English
+// stemming. Words of other languages are stemmed anyway.
+func TestSimilarity_Similarity_Stemming(t *testing.T) {
+	for name, tc := range map[string]struct {
+		original     string
+		reprint      string
+		expected     float64
+		expectedStem float64
+	}{
+		"when english inflections": {
+			original:     "The minister walked to the parliament and answered questions about the new articles",
+			reprint:      "The minister walks to parliament and answers a question about new article",
+			expected:     0.43,
+			expectedStem: 1,
+		},
+		"when english irregular verb": {
+			original:     "Police arrested two suspects who broke windows of the national gallery",
+			reprint:      "Police arrest two suspects who break window of the national gallery",
+			expected:     0.75,
+			expectedStem: 1,
+		},
+		"when german": {
+			original:     "Die Regierung plant neue Gesetze für kleine Unternehmen",
+			reprint:      "Die Regierung plante ein neues Gesetz für kleinere Unternehmen",
+			expected:     1,
+			expectedStem: 1,
+		},
+		"when russian": {
+			original:     "Учёные опубликовали новые исследования о климате",
+			reprint:      "Учёный опубликовал новое исследование о климате",
+			expected:     1,
+			expectedStem: 1,
+		},
+		"when ukrainian": {
+			original:     "Вчена опублікувала нові дослідження про клімат",
+			reprint:      "Вчені опублікували нове дослідження про клімат",
+			expected:     1,
+			expectedStem: 1,
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			irregular := IrregularVerb{}
+			require.NoError(t, irregular.LoadDefault())
+
+			n := DefaultNormalizer(irregular)
+			sim := NewSimilarity(0.95, 1, n, NewWordLevenshtein())
+
+			n.StemEnglish = true
+			stemSim := NewSimilarity(0.95, 1, n, NewWordLevenshtein())
+
+			assert.InDelta(t, tc.expected, sim.Similarity(1, tc.original, 2, tc.reprint), 0.01)
+			assert.InDelta(t, tc.expectedStem, stemSim.Similarity(1, tc.original, 2, tc.reprint), 0.01)
+		})
+	}
+}
+
+func TestSimilarity_Explain_Transposition(t *testing.T) {
+	sim := NewSimilarity(0.7, 1, EnglishNormalizer(IrregularVerb{}), NewWordDamerauLevenshtein())
+
+	res := sim.Explain(sim.Tokenize("World hello, beautiful!"), sim.Tokenize("hello world, beautiful"))
+
+	assert.Equal(t, 1, res.Distance)
+	assert.InDelta(t, 2.0/3, res.Similarity, 1e-9)
+	assert.Equal(t, []articlesim.EditOp{
+		{Operation: "transpose", Position: 0, OtherPosition: 0, Word: "world", OtherWord: "hello"},
+	}, res.EditScript)
+}
@@ -0,0 +1,63 @@
+package similarity
+
+import (
+	"unicode/utf8"
+)
+
+// hasSuffix reports whether the word ends with the suffix.
+func hasSuffix(word []rune, suffix string) bool {
+	s := []rune(suffix)
+	if len(s) > len(word) {
+		return false
+	}
+
+	for i := range s {
+		if word[len(word)-len(s)+i] != s[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// longestSuffix returns the longest of the suffixes the word ends with or "" when it ends with none of them.
+func longestSuffix(word []rune, suffixes []string) string {
+	longest := ""
+
+	for _, s := range suffixes {
+		if utf8.RuneCountInString(s) > utf8.RuneCountInString(longest) && hasSuffix(word, s) {
+			longest = s
+		}
+	}
+
+	return longest
+}
+
+// suffixStart returns the index of the first rune of the suffix in the word ending with it.
+func suffixStart(word []rune, suffix string) int {
+	return len(word) - utf8.RuneCountInString(suffix)
+}
+
+// region returns the index after the first non-vowel following a vowel in the word from the start,
+// or the length of the word when there is no such non-vowel. It is R1 of Snowball stemmers for the start 0
+// and R2 for the start R1.
+func region(word []rune, start int, isVowel func(r rune) bool) int {
+	for i := start + 1; i < len(word); i++ {
+		if isVowel(word[i-1]) && !isVowel(word[i]) {
+			return i + 1
+		}
+	}
+
+	return len(word)
+}
+
+// isRuneOf reports whether the rune is one of the runes of the set.
+func isRuneOf(r rune, set string) bool {
+	for _, s := range set {
+		if r == s {
+			return true
+		}
+	}
+
+	return false
+}
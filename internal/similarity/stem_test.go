@@ -0,0 +1,84 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStemmer_Stem(t *testing.T) {
+	for name, tc := range map[string]struct {
+		stemmer  Stemmer
+		words    []string
+		expected string
+	}{
+		"when english": {
+			stemmer:  PorterStemmer{},
+			words:    []string{"connect", "connected", "connecting", "connection", "connections"},
+			expected: "connect",
+		},
+		"when english derivational suffixes": {
+			stemmer:  PorterStemmer{},
+			words:    []string{"generalizations", "general", "generally"},
+			expected: "gener",
+		},
+		"when german plural": {
+			stemmer:  GermanStemmer{},
+			words:    []string{"haus", "häuser", "hauses"},
+			expected: "haus",
+		},
+		"when german derivational suffixes": {
+			stemmer:  GermanStemmer{},
+			words:    []string{"freundlich", "freundlichkeit", "freundlichkeiten"},
+			expected: "freundlich",
+		},
+		"when german ig before end and ung": {
+			stemmer:  GermanStemmer{},
+			words:    []string{"erledigung", "erledigend", "erledigen"},
+			expected: "erled",
+		},
+		"when german ig before ung out of r2": {
+			stemmer:  GermanStemmer{},
+			words:    []string{"reinigung", "reinigen"},
+			expected: "reinig",
+		},
+		"when russian noun": {
+			stemmer:  RussianStemmer{},
+			words:    []string{"статья", "статьи", "статей", "статьями"},
+			expected: "стат",
+		},
+		"when russian verb": {
+			stemmer:  RussianStemmer{},
+			words:    []string{"читает", "читали", "читавши"},
+			expected: "чита",
+		},
+		"when russian adjective": {
+			stemmer:  RussianStemmer{},
+			words:    []string{"красивая", "красивого", "красивые"},
+			expected: "красив",
+		},
+		"when ukrainian noun": {
+			stemmer:  UkrainianStemmer{},
+			words:    []string{"стаття", "статті", "статтю", "статей"},
+			expected: "стат",
+		},
+		"when ukrainian verb": {
+			stemmer:  UkrainianStemmer{},
+			words:    []string{"читає", "читали", "читаючи", "читав"},
+			expected: "чита",
+		},
+		"when ukrainian adjective": {
+			stemmer:  UkrainianStemmer{},
+			words:    []string{"зелена", "зеленого", "зеленими"},
+			expected: "зелен",
+		},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			for _, w := range tc.words {
+				assert.Equal(t, tc.expected, tc.stemmer.Stem(w), w)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package similarity
+
+import (
+	"unicode"
+)
+
+// Ukrainian returns the profile of Ukrainian language.
+func Ukrainian() *Language {
+	return newLanguage(LanguageUkrainian, unicode.Cyrillic, ukrainianSample, ukrainianStopWords, UkrainianStemmer{})
+}
+
+// UkrainianStemmer is a light stemmer of Ukrainian words following the steps of the Snowball Russian stemmer.
+type UkrainianStemmer struct{}
+
+// Stem returns the stem of the lower-cased Ukrainian word.
+func (UkrainianStemmer) Stem(word string) string {
+	w := []rune(word)
+	rv := regionAfterVowel(w, isUkrainianVowel)
+	r2 := region(w, region(w, 0, isUkrainianVowel), isUkrainianVowel) - rv
+
+	prefix, w := w[:rv], w[rv:]
+
+	if s, ok := removeEnding(w, "", nil, []string{
+		"ючи", "учи", "ачи", "ячи", "ючись", "учись", "ачись", "ячись", "вши", "вшись", "ивши", "ившись",
+	}); ok {
+		w = s
+	} else {
+		if s, ok := removeEnding(w, "", nil, []string{"ся", "сь"}); ok {
+			w = s
+		}
+
+		w = ukrainianRemoveInflection(w)
+	}
+
+	if s := longestSuffix(w, []string{"ість", "ост"}); s != "" && suffixStart(w, s) >= r2 {
+		w = w[:suffixStart(w, s)]
+	}
+
+	// Ukrainian doubles consonants before endings: стаття, знання, життя.
+	if n := len(w); hasSuffix(w, "ь") || n > 1 && w[n-1] == w[n-2] && !isUkrainianVowel(w[n-1]) {
+		w = w[:n-1]
+	}
+
+	return string(prefix) + string(w)
+}
+
+// ukrainianRemoveInflection removes an adjectival, a verb or a noun ending, whichever is found first.
+func ukrainianRemoveInflection(w []rune) []rune {
+	if s, ok := removeEnding(w, "", nil, []string{
+		"ий", "ій", "ого", "ього", "ому", "ьому", "им", "ім", "ими", "іми", "их", "іх", "ої", "єї", "ою", "єю", "ую",
+		"юю", "ая", "яя", "еє", "ее",
+	}); ok {
+		if p, ok := removeEnding(s, "", nil, []string{"уч", "юч", "ач", "яч"}); ok {
+			return p
+		}
+
+		return s
+	}
+
+	if s, ok := removeEnding(w, "ая", []string{
+		"ла", "ло", "ли", "в", "ти", "ть", "ю", "єш", "є", "ємо", "єте", "ють", "ймо", "йте", "й", "тиме", "тимуть",
+		"тимемо", "тимеш", "тимете",
+	}, []string{
+		"ила", "ило", "или", "ив", "ити", "ить", "іла", "іло", "іли", "ів", "іти", "іть", "ать", "ять", "уть", "ують",
+		"юють", "емо", "имо", "імо", "ете", "ите", "іте", "еш", "иш", "іш",
+	}); ok {
+		return s
+	}
+
+	s, _ := removeEnding(w, "", nil, []string{
+		"а", "ам", "ами", "ах", "я", "ям", "ями", "ях", "ею", "єю", "ою", "ові", "еві", "єві", "ом", "ем", "єм", "у",
+		"ю", "і", "ї", "и", "о", "е", "є", "ів", "їв", "ей", "ь", "ью", "ія", "ії", "ію", "ією", "ієм", "ій",
+	})
+
+	return s
+}
+
+func isUkrainianVowel(r rune) bool {
+	return isRuneOf(r, "аеєиіїоуюя")
+}
+
+const ukrainianStopWords = `
+і й та а але в у на з із зі до від по за про для під над при через без між біля після перед що як це цей ця ці
+той ті так не ні чи же ж би б бо коли якщо де тут там вже ще теж також тільки лише він вона воно вони я ти
+ми ви його її їх їм йому їй мене мені тебе тобі нас нам вас вам себе свій своя своє свої мій моя моє мої наш наша
+наше наші ваш ваша ваше ваші який яка яке які котрий все всі весь вся було був була були бути є буде будуть може
+можна треба дуже більш більше менше тому адже навіть хоча поки ось
+`
+
+const ukrainianSample = `
+Інформаційні агенції щодня публікують тисячі статей, і багато з них передруковують інші видання з невеликими
+змінами. Сервіс зберігає кожну статтю і знаходить ті, що мають майже такий самий зміст. Коли журналістка пише
+нову історію про погоду, економіку чи уряд, текст порівнюється з історіями, які були опубліковані раніше. Якщо
+слова обох текстів майже збігаються, нова стаття позначається як дублікат і потрапляє до групи початкової. Читачі
+хочуть бачити першоджерело, а не копії. Тому порядок статей важливий: найстаріша стаття групи вважається
+унікальною. Міська рада повідомила в понеділок, що новий міст відкриють для руху наступного року. Ціни на
+продукти та енергію знову зросли, і очікується, що центральний банк підвищить відсоткові ставки. Науковці
+з'ясували, що діти, які проводять більше часу на вулиці, здоровіші та щасливіші за тих, хто залишається вдома.
+Футбольна команда виграла матч після довгого і важкого сезону, і тисячі вболівальників святкували на вулицях
+столиці. Хоча погода була холодною, вони пішли гуляти парком і говорили про те, що бачили під час подорожі. Немає
+нічого нового під сонцем, але кожен письменник вірить, що його слова варто прочитати.
+`
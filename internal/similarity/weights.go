@@ -0,0 +1,95 @@
+package similarity
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	weightColumns = 2
+
+	defaultWeight = 1.0
+)
+
+var ErrInvalidWeight = errors.New("invalid word weight")
+
+// Weights are weights of normalized words, e.g. inverse document frequencies of words in a corpus. Words without
+// weight weigh 1, so common words should weigh less than 1 and rare words more.
+//
+// Weights are looked up by normalized words as they are compared, so the words must be normalized with the same
+// normalization, e.g. stemmed words taken from the words of the compare endpoint. Surface words which are changed
+// by the normalization never match.
+type Weights struct {
+	// weights maps normalized words to weights.
+	weights map[string]float64
+}
+
+// Load loads weights of words from the CSV file with word and weight columns.
+func (w *Weights) Load(weightFilePath string) error {
+	file, err := os.Open(weightFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file=%s: %w", weightFilePath, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("file close failed: %v", err)
+		}
+	}()
+
+	return w.Read(file)
+}
+
+// Read reads weights of normalized words in CSV format with word and weight columns. Weights must be finite
+// non-negative numbers. Words are kept as they are, they are neither lower-cased nor normalized.
+func (w *Weights) Read(r io.Reader) error {
+	reader := csv.NewReader(r)
+
+	w.weights = make(map[string]float64)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read: %w", err)
+		}
+
+		if len(record) < weightColumns {
+			continue
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return fmt.Errorf("%w: %s=%s", ErrInvalidWeight, record[0], record[1])
+		}
+
+		w.weights[strings.TrimSpace(record[0])] = weight
+	}
+
+	return nil
+}
+
+// Weight returns the weight of the normalized word, 1 for words without weight.
+func (w Weights) Weight(word string) float64 {
+	weight, ok := w.weights[word]
+	if !ok {
+		return defaultWeight
+	}
+
+	return weight
+}
+
+// isEmpty reports whether no word has weight, so all words weigh 1.
+func (w Weights) isEmpty() bool {
+	return len(w.weights) == 0
+}
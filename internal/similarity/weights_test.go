@@ -0,0 +1,39 @@
+package similarity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeights_Weight(t *testing.T) {
+	weights := Weights{}
+	require.NoError(t, weights.Read(strings.NewReader("and,0.1\n senat, 3.5\nPresident,2\n")))
+
+	assert.Equal(t, 0.1, weights.Weight("and"))
+	assert.Equal(t, 3.5, weights.Weight("senat"))
+	assert.Equal(t, 1.0, weights.Weight("senate"))
+	assert.Equal(t, 1.0, weights.Weight("president"))
+}
+
+func TestWeights_Read_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"when not a number":   "and,often",
+		"when negative value": "and,-1",
+		"when not a finite":   "and,NaN",
+		"when infinite":       "and,+Inf",
+	} {
+		content := content
+
+		t.Run(name, func(t *testing.T) {
+			weights := Weights{}
+
+			err := weights.Read(strings.NewReader(content))
+
+			assert.True(t, errors.Is(err, ErrInvalidWeight))
+		})
+	}
+}
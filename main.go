@@ -7,7 +7,7 @@ import (
 )
 
 func main() {
-	if err := cmd.ExecuteServer(); err != nil {
+	if err := cmd.Execute(); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -43,39 +43,39 @@ func (s *e2eTestSuite) Test_EndToEnd_Success() {
 
 	// POST /articles {"content": "..."} -> 201
 	s.AssertRequestResponse(http.MethodPost, "/articles", `{"content":"first"}`,
-		http.StatusCreated, `{"content":"first","duplicate_article_ids":[],"id":1}`)
+		http.StatusCreated, `{"content":"first","duplicate_article_ids":[],"duplicates":[],"id":1}`)
 
 	// POST /articles {"content": "..."} -> 201
 	s.AssertRequestResponse(http.MethodPost, "/articles", `{"content":"First!"}`,
-		http.StatusCreated, `{"content":"First!","duplicate_article_ids":[1],"id":2}`)
+		http.StatusCreated, `{"content":"First!","duplicate_article_ids":[1],"duplicates":[{"algorithm":"levenshtein","id":1,"score":1,"threshold":0.95}],"id":2}`)
 
 	// GET /articles/2 -> 200
 	s.AssertRequestResponse(http.MethodGet, "/articles/2", "",
-		http.StatusOK, `{"content":"First!","duplicate_article_ids":[1],"id":2}`)
+		http.StatusOK, `{"content":"First!","duplicate_article_ids":[1],"duplicates":[{"algorithm":"levenshtein","id":1,"score":1,"threshold":0.95}],"id":2}`)
 
 	// POST /articles {"content": "..."} -> 201
 	s.AssertRequestResponse(http.MethodPost, "/articles", `{"content":"second"}`,
-		http.StatusCreated, `{"content":"second","duplicate_article_ids":[],"id":3}`)
+		http.StatusCreated, `{"content":"second","duplicate_article_ids":[],"duplicates":[],"id":3}`)
 
 	// POST /articles {"content": "..."} -> 201
 	s.AssertRequestResponse(http.MethodPost, "/articles", `{"content":"the first"}`,
-		http.StatusCreated, `{"content":"the first","duplicate_article_ids":[1,2],"id":4}`)
+		http.StatusCreated, `{"content":"the first","duplicate_article_ids":[1,2],"duplicates":[{"algorithm":"levenshtein","id":1,"score":1,"threshold":0.95},{"algorithm":"levenshtein","id":2,"score":1,"threshold":0.95}],"id":4}`)
 
 	// GET /articles/2 -> 200
 	s.AssertRequestResponse(http.MethodGet, "/articles/2", "",
-		http.StatusOK, `{"content":"First!","duplicate_article_ids":[1,4],"id":2}`)
+		http.StatusOK, `{"content":"First!","duplicate_article_ids":[1,4],"duplicates":[{"algorithm":"levenshtein","id":1,"score":1,"threshold":0.95},{"algorithm":"levenshtein","id":4,"score":1,"threshold":0.95}],"id":2}`)
 
 	// GET /articles -> 200
 	s.AssertRequestResponse(http.MethodGet, "/articles", "",
-		http.StatusOK, `{"articles":[{"content":"first","duplicate_article_ids":[],"id":1},{"content":"second","duplicate_article_ids":[],"id":3}]}`)
+		http.StatusOK, `{"articles":[{"content":"first","duplicate_article_ids":[],"duplicates":[],"id":1},{"content":"second","duplicate_article_ids":[],"duplicates":[],"id":3}]}`)
 
 	// POST /articles {"content": "..."} -> 201
 	s.AssertRequestResponse(http.MethodPost, "/articles", `{"content":"go go go"}`,
-		http.StatusCreated, `{"content":"go go go","duplicate_article_ids":[],"id":5}`)
+		http.StatusCreated, `{"content":"go go go","duplicate_article_ids":[],"duplicates":[],"id":5}`)
 
 	// POST /articles {"content": "..."} -> 201
 	s.AssertRequestResponse(http.MethodPost, "/articles", `{"content":"go went gone"}`,
-		http.StatusCreated, `{"content":"go went gone","duplicate_article_ids":[5],"id":6}`)
+		http.StatusCreated, `{"content":"go went gone","duplicate_article_ids":[5],"duplicates":[{"algorithm":"levenshtein","id":5,"score":1,"threshold":0.95}],"id":6}`)
 
 	// GET /duplicate_groups -> 200
 	s.AssertRequestResponse(http.MethodGet, "/duplicate_groups", ``,